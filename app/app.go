@@ -3,41 +3,86 @@ package app
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
+	"github.com/sankt-petersbug/shakesearch/render"
 	"github.com/sankt-petersbug/shakesearch/store"
 )
 
-var errorHandler = func(c *fiber.Ctx, err error) error {
+// toFiberError converts an error to the error returned to users
+func toFiberError(err error) *fiber.Error {
 	if e, ok := err.(*fiber.Error); ok {
-		return c.Status(e.Code).JSON(e)
+		return e
 	}
-	code := fiber.StatusInternalServerError
-	return c.Status(code).JSON(fiber.Map{
-		"code":    code,
-		"message": "Internal Server Error",
-	})
+	return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+}
+
+const (
+	// apiV1Prefix is the prefix of the version 1 JSON endpoints
+	apiV1Prefix = "/api/v1"
+	// legacySunset is the date the endpoints without a version prefix will be removed
+	legacySunset = "Wed, 30 Jun 2027 00:00:00 GMT"
+)
+
+var errorHandler = func(c *fiber.Ctx, err error) error {
+	e := toFiberError(err)
+	return c.Status(e.Code).JSON(e)
 }
 
 type Store interface {
 	ListTitles() []store.Title
 	GetWorkByID(id string) (store.ShakespeareWork, error)
+	GetWorkByEdition(id string, edition string) (store.ShakespeareWork, error)
 	Search(options store.SearchOptions) (store.SearchResult, error)
+	Concordance(options store.ConcordanceOptions) (store.Concordance, error)
+	TermFrequencies(workID string, edition string, top int) ([]store.TermFrequency, error)
+	TermStats(term string, edition string) (store.TermStats, error)
+	Collocations(options store.CollocationOptions) ([]store.Collocation, error)
+	Trend(term string, edition string) (store.Trend, error)
+	Scan(options store.SearchOptions, fn func(store.Hit) error) error
+	Diff(id string, from string, to string) (store.Diff, error)
+	Characters(workID string, edition string) ([]store.Character, error)
+	GetCharacter(id string, edition string) (store.Character, error)
+	Network(workID string, edition string) (store.Network, error)
+	CharacterStats(workID string, name string, edition string) (store.CharacterStats, error)
+	Quote(options store.QuoteOptions) (store.Quotation, error)
+}
+
+// Indexer adds works to a Store
+type Indexer interface {
+	BatchIndex(works []store.ShakespeareWork) error
+}
+
+// Config represents the settings of the server chosen by the operator
+type Config struct {
+	// MaxExportResults is the maximum number of hits of a search export
+	MaxExportResults int
+}
+
+// DefaultConfig returns the settings used when the operator does not set them
+func DefaultConfig() Config {
+	return Config{
+		MaxExportResults: defaultMaxExportResults,
+	}
 }
 
 type App struct {
-	store *store.BleveStore
-	api   *fiber.App
+	indexer Indexer
+	api     *fiber.App
+	rpc     *grpc.Server
 }
 
 // Load loads data to the store
 func (a *App) Load(works []store.ShakespeareWork) error {
 	log.Info("Start indexing documents")
 	start := time.Now()
-	if err := a.store.BatchIndex(works); err != nil {
+	if err := a.indexer.BatchIndex(works); err != nil {
 		return err
 	}
 	duration := time.Since(start)
@@ -51,54 +96,184 @@ func (a *App) Listen(port string) error {
 	return a.api.Listen(addr)
 }
 
-// NewApp initializes and returns a server app
-func NewApp() (*App, error) {
-	bleveStore, err := store.NewBleveStore(false)
+// ListenGRPC runs the gRPC server on a port
+func (a *App) ListenGRPC(port string) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	app := &App{
-		store: bleveStore,
-		api:   newFiberApp(bleveStore),
+	return a.rpc.Serve(lis)
+}
+
+// NewApp initializes and returns a server app serving s. Load adds works to s through
+// the indexer, which is usually s itself.
+func NewApp(s Store, indexer Indexer, config Config) *App {
+	return &App{
+		indexer: indexer,
+		api:     newFiberApp(s, config),
+		rpc:     newGRPCServer(s, config),
 	}
-	return app, nil
 }
 
-func newFiberApp(s Store) *fiber.App {
-	app := fiber.New(fiber.Config{
-		ErrorHandler: errorHandler,
-	})
-	app.Static("/", "./static")
-	app.Get("/titles", func(c *fiber.Ctx) error {
+func titlesHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		return c.JSON(s.ListTitles())
-	})
-	app.Get("/works/:id", func(c *fiber.Ctx) error {
+	}
+}
+
+// workFormat represents a document format works can be returned in
+type workFormat struct {
+	contentType string
+	extension   string
+	render      func(w io.Writer, work store.ShakespeareWork) error
+}
+
+var workFormats = map[string]workFormat{
+	"text":     {contentType: "text/plain; charset=utf-8", extension: "txt", render: renderText},
+	"markdown": {contentType: "text/markdown; charset=utf-8", extension: "md", render: render.Markdown},
+	"tei":      {contentType: "application/tei+xml; charset=utf-8", extension: "xml", render: render.TEI},
+	"epub":     {contentType: "application/epub+zip", extension: "epub", render: render.EPUB},
+}
+
+// workMediaTypes are the media types of the Accept header of each work format,
+// in order of preference
+var workMediaTypes = []struct {
+	mediaType string
+	format    string
+}{
+	{mediaType: fiber.MIMEApplicationJSON, format: "json"},
+	{mediaType: "text/plain", format: "text"},
+	{mediaType: "text/markdown", format: "markdown"},
+	{mediaType: "application/tei+xml", format: "tei"},
+	{mediaType: "application/xml", format: "tei"},
+	{mediaType: "text/xml", format: "tei"},
+	{mediaType: "application/epub+zip", format: "epub"},
+}
+
+func renderText(w io.Writer, work store.ShakespeareWork) error {
+	_, err := io.WriteString(w, work.Content)
+	return err
+}
+
+// workFormatOf returns the format requested with the format query param or else the Accept header
+func workFormatOf(c *fiber.Ctx) (string, error) {
+	if format := c.Query("format"); format != "" {
+		if _, ok := workFormats[format]; !ok && format != "json" {
+			return "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid format: %s", format))
+		}
+		return format, nil
+	}
+	offers := make([]string, 0, len(workMediaTypes))
+	for _, t := range workMediaTypes {
+		offers = append(offers, t.mediaType)
+	}
+	accepted := c.Accepts(offers...)
+	for _, t := range workMediaTypes {
+		if t.mediaType == accepted {
+			return t.format, nil
+		}
+	}
+	return "", fiber.NewError(fiber.StatusNotAcceptable, "works are available as JSON, plain text, Markdown, TEI XML or EPUB")
+}
+
+// getWork returns a work in the edition, or in the default one of the store if edition is empty.
+// The error wrapping store.ErrWorkNotFound names the work.
+func getWork(s Store, id string, edition string) (store.ShakespeareWork, error) {
+	var work store.ShakespeareWork
+	var err error
+	if edition == "" {
+		work, err = s.GetWorkByID(id)
+	} else {
+		work, err = s.GetWorkByEdition(id, edition)
+	}
+	if errors.Is(err, store.ErrWorkNotFound) {
+		if edition == "" {
+			return work, fmt.Errorf("%w: %s", err, id)
+		}
+		return work, fmt.Errorf("%w: %s in edition %s", err, id, edition)
+	}
+	return work, err
+}
+
+func workHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		id := c.Params("id")
-		work, err := s.GetWorkByID(id)
+		format, err := workFormatOf(c)
 		if err != nil {
-			if errors.Is(err, store.ErrWorkNotFound) {
-				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("work not found: %s", id))
-			}
 			return err
 		}
-		return c.JSON(work)
-	})
-	app.Get("/search", func(c *fiber.Ctx) error {
-		options := store.SearchOptions{
-			PageSize:   20,
-			PageNumber: 1,
-			SortBy:     []string{"Title", "LineNumber"}, // TODO: case insensitive sort by options
-		}
-		if err := c.QueryParser(&options); err != nil {
-			fmt.Println(err)
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-		searchResult, err := s.Search(options)
+		work, err := getWork(s, id, c.Query("edition"))
 		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, err.Error())
+			}
 			return err
 		}
-		return c.JSON(searchResult)
+		if format == "json" {
+			return c.JSON(work)
+		}
+
+		wf := workFormats[format]
+		c.Set(fiber.HeaderContentType, wf.contentType)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.%s"`, work.ID, wf.extension))
+		return wf.render(c, work)
+	}
+}
+
+// deprecated marks the response as coming from a deprecated endpoint and
+// links to the endpoint replacing it under the prefix
+func deprecated(prefix string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		c.Set("Sunset", legacySunset)
+		c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`, prefix, c.Path()))
+		return c.Next()
+	}
+}
+
+// registerV1Routes registers the version 1 JSON endpoints, running the middleware
+// before each of them. A version changing response shapes gets its own group and
+// register function, reusing the handlers that did not change.
+func registerV1Routes(router fiber.Router, s Store, config Config, middleware ...fiber.Handler) {
+	handle := func(method, path string, handler fiber.Handler) {
+		handlers := append(append([]fiber.Handler{}, middleware...), handler)
+		router.Add(method, path, handlers...)
+	}
+	handle(fiber.MethodGet, "/titles", titlesHandler(s))
+	handle(fiber.MethodGet, "/works/:id", workHandler(s))
+	handle(fiber.MethodGet, "/works/:id/diff", diffHandler(s))
+	handle(fiber.MethodGet, "/works/:id/characters", charactersHandler(s))
+	handle(fiber.MethodGet, "/works/:id/characters/:name/stats", characterStatsHandler(s))
+	handle(fiber.MethodGet, "/characters/:id", characterHandler(s))
+	handle(fiber.MethodGet, "/works/:id/network", networkHandler(s))
+	handle(fiber.MethodGet, "/search", searchHandler(s))
+	handle(fiber.MethodPost, "/search", postSearchHandler(s))
+	handle(fiber.MethodPost, "/search/batch", batchSearchHandler(s))
+	handle(fiber.MethodGet, "/search/export", exportHandler(s, config.MaxExportResults))
+	handle(fiber.MethodGet, "/concordance", concordanceHandler(s))
+	handle(fiber.MethodGet, "/quote", quoteHandler(s))
+	handle(fiber.MethodGet, "/stats/terms", termFrequenciesHandler(s))
+	handle(fiber.MethodGet, "/stats/term/:term", termStatsHandler(s))
+	handle(fiber.MethodGet, "/stats/collocations", collocationsHandler(s))
+	handle(fiber.MethodGet, "/stats/trend", trendHandler(s))
+	graphql := graphqlHandler(s)
+	handle(fiber.MethodGet, graphqlPath, graphql)
+	handle(fiber.MethodPost, graphqlPath, graphql)
+
+	spec := newOpenAPISpec(apiV1Prefix, operations)
+	handle(fiber.MethodGet, openAPIPath, func(c *fiber.Ctx) error {
+		return c.JSON(spec)
+	})
+}
+
+func newFiberApp(s Store, config Config) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: errorHandler,
 	})
+	registerV1Routes(app.Group(apiV1Prefix), s, config)
+	// routes without a version prefix are kept for existing clients
+	registerV1Routes(app, s, config, deprecated(apiV1Prefix))
+	app.Static("/", "./static")
 	log.Info("Initialized api")
 	return app
 }
@@ -7,8 +7,10 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
@@ -24,7 +26,20 @@ func init() {
 type fakeStore struct {
 	listTitlesFunc  func() []store.Title
 	getWorkByIDFunc func(id string) (store.ShakespeareWork, error)
+	getWorkByEdFunc func(id string, edition string) (store.ShakespeareWork, error)
 	searchFunc      func(store.SearchOptions) (store.SearchResult, error)
+	concordanceFunc func(store.ConcordanceOptions) (store.Concordance, error)
+	termFreqsFunc   func(workID string, edition string, top int) ([]store.TermFrequency, error)
+	termStatsFunc   func(term string, edition string) (store.TermStats, error)
+	collocsFunc     func(store.CollocationOptions) ([]store.Collocation, error)
+	trendFunc       func(term string, edition string) (store.Trend, error)
+	scanFunc        func(store.SearchOptions, func(store.Hit) error) error
+	diffFunc        func(id string, from string, to string) (store.Diff, error)
+	charactersFunc  func(workID string, edition string) ([]store.Character, error)
+	characterFunc   func(id string, edition string) (store.Character, error)
+	networkFunc     func(workID string, edition string) (store.Network, error)
+	charStatsFunc   func(workID string, name string, edition string) (store.CharacterStats, error)
+	quoteFunc       func(store.QuoteOptions) (store.Quotation, error)
 }
 
 func (f *fakeStore) ListTitles() []store.Title {
@@ -41,6 +56,13 @@ func (f *fakeStore) GetWorkByID(id string) (store.ShakespeareWork, error) {
 	return store.ShakespeareWork{ID: id}, nil
 }
 
+func (f *fakeStore) GetWorkByEdition(id string, edition string) (store.ShakespeareWork, error) {
+	if f.getWorkByEdFunc != nil {
+		return f.getWorkByEdFunc(id, edition)
+	}
+	return store.ShakespeareWork{ID: id, Edition: edition}, nil
+}
+
 func (f *fakeStore) Search(options store.SearchOptions) (store.SearchResult, error) {
 	if f.searchFunc != nil {
 		return f.searchFunc(options)
@@ -48,6 +70,94 @@ func (f *fakeStore) Search(options store.SearchOptions) (store.SearchResult, err
 	return store.SearchResult{}, nil
 }
 
+func (f *fakeStore) Concordance(options store.ConcordanceOptions) (store.Concordance, error) {
+	if f.concordanceFunc != nil {
+		return f.concordanceFunc(options)
+	}
+	return store.Concordance{}, nil
+}
+
+func (f *fakeStore) TermFrequencies(workID string, edition string, top int) ([]store.TermFrequency, error) {
+	if f.termFreqsFunc != nil {
+		return f.termFreqsFunc(workID, edition, top)
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) TermStats(term string, edition string) (store.TermStats, error) {
+	if f.termStatsFunc != nil {
+		return f.termStatsFunc(term, edition)
+	}
+	return store.TermStats{Term: term}, nil
+}
+
+func (f *fakeStore) Collocations(options store.CollocationOptions) ([]store.Collocation, error) {
+	if f.collocsFunc != nil {
+		return f.collocsFunc(options)
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) Trend(term string, edition string) (store.Trend, error) {
+	if f.trendFunc != nil {
+		return f.trendFunc(term, edition)
+	}
+	return store.Trend{Term: term}, nil
+}
+
+func (f *fakeStore) Scan(options store.SearchOptions, fn func(store.Hit) error) error {
+	if f.scanFunc != nil {
+		return f.scanFunc(options, fn)
+	}
+	return nil
+}
+
+func (f *fakeStore) Diff(id string, from string, to string) (store.Diff, error) {
+	if f.diffFunc != nil {
+		return f.diffFunc(id, from, to)
+	}
+	return store.Diff{WorkID: id, From: from, To: to}, nil
+}
+
+func (f *fakeStore) Characters(workID string, edition string) ([]store.Character, error) {
+	if f.charactersFunc != nil {
+		return f.charactersFunc(workID, edition)
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) GetCharacter(id string, edition string) (store.Character, error) {
+	if f.characterFunc != nil {
+		return f.characterFunc(id, edition)
+	}
+	return store.Character{ID: id}, nil
+}
+
+func (f *fakeStore) Network(workID string, edition string) (store.Network, error) {
+	if f.networkFunc != nil {
+		return f.networkFunc(workID, edition)
+	}
+	return store.Network{WorkID: workID}, nil
+}
+
+func (f *fakeStore) CharacterStats(workID string, name string, edition string) (store.CharacterStats, error) {
+	if f.charStatsFunc != nil {
+		return f.charStatsFunc(workID, name, edition)
+	}
+	return store.CharacterStats{WorkID: workID, Name: name}, nil
+}
+
+func (f *fakeStore) Quote(options store.QuoteOptions) (store.Quotation, error) {
+	if f.quoteFunc != nil {
+		return f.quoteFunc(options)
+	}
+	return store.Quotation{Matches: []store.QuoteMatch{}, Text: options.Text}, nil
+}
+
+func newTestApp(s Store) *fiber.App {
+	return newFiberApp(s, DefaultConfig())
+}
+
 func readRespBody(body io.ReadCloser) (store.ShakespeareWork, error) {
 	defer body.Close()
 	var work store.ShakespeareWork
@@ -63,7 +173,7 @@ func readRespBody(body io.ReadCloser) (store.ShakespeareWork, error) {
 }
 
 func TestRoute_WorkByID_Success(t *testing.T) {
-	app := newFiberApp(&fakeStore{})
+	app := newTestApp(&fakeStore{})
 	req, err := http.NewRequest("GET", "/works/1", nil)
 	if err != nil {
 		panic(err)
@@ -78,6 +188,28 @@ func TestRoute_WorkByID_Success(t *testing.T) {
 	assert.Equal(t, "1", work.ID)
 }
 
+func TestRoute_WorkByID_Edition(t *testing.T) {
+	app := newTestApp(&fakeStore{
+		getWorkByEdFunc: func(id string, edition string) (store.ShakespeareWork, error) {
+			if edition != "folger" {
+				return store.ShakespeareWork{}, store.ErrWorkNotFound
+			}
+			return store.ShakespeareWork{ID: id, Edition: edition}, nil
+		},
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/works/1?edition=folger", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	work, err := readRespBody(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "folger", work.Edition)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/v1/works/1?edition=folio", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
 func TestRoute_WorkByID_Errors(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -97,7 +229,7 @@ func TestRoute_WorkByID_Errors(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			app := newFiberApp(&fakeStore{
+			app := newTestApp(&fakeStore{
 				getWorkByIDFunc: tc.getWorkByIDFunc,
 			})
 			req, err := http.NewRequest("GET", fmt.Sprintf("/works/%s", tc.id), nil)
@@ -112,8 +244,102 @@ func TestRoute_WorkByID_Errors(t *testing.T) {
 	}
 }
 
+func TestRoute_WorkByID_Formats(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		accept      string
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "default",
+			url:         "/api/v1/works/1",
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id":"1","title":"","content":"ACT I"}`,
+		},
+		{
+			name:        "any",
+			url:         "/api/v1/works/1",
+			accept:      "text/html, */*;q=0.8",
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+		},
+		{
+			name:        "text",
+			url:         "/api/v1/works/1",
+			accept:      "text/plain",
+			statusCode:  http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "ACT I",
+		},
+		{
+			name:        "markdown",
+			url:         "/api/v1/works/1?format=markdown",
+			statusCode:  http.StatusOK,
+			contentType: "text/markdown; charset=utf-8",
+		},
+		{
+			name:        "tei",
+			url:         "/api/v1/works/1",
+			accept:      "application/xml",
+			statusCode:  http.StatusOK,
+			contentType: "application/tei+xml; charset=utf-8",
+		},
+		{
+			name:        "format overrides accept",
+			url:         "/api/v1/works/1?format=epub",
+			accept:      "text/plain",
+			statusCode:  http.StatusOK,
+			contentType: "application/epub+zip",
+		},
+		{
+			name:       "invalid format",
+			url:        "/api/v1/works/1?format=pdf",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "not acceptable",
+			url:        "/api/v1/works/1",
+			accept:     "application/pdf",
+			statusCode: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{
+				getWorkByIDFunc: func(id string) (store.ShakespeareWork, error) {
+					return store.ShakespeareWork{ID: id, Content: "ACT I"}, nil
+				},
+			})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+			if tc.contentType != "" {
+				assert.Equal(t, tc.contentType, resp.Header.Get("Content-Type"))
+			}
+			if tc.body != "" {
+				byt, err := ioutil.ReadAll(resp.Body)
+				assert.Nil(t, err)
+				assert.Equal(t, tc.body, string(byt))
+			}
+		})
+	}
+}
+
 func TestRoute_Search_InvalidQueryParams(t *testing.T) {
-	app := newFiberApp(&fakeStore{})
+	app := newTestApp(&fakeStore{})
 	req, err := http.NewRequest("GET", "/search?fuzziness=yes", nil)
 	if err != nil {
 		panic(err)
@@ -125,7 +351,7 @@ func TestRoute_Search_InvalidQueryParams(t *testing.T) {
 }
 
 func TestRoute_Search_SearcherError(t *testing.T) {
-	app := newFiberApp(&fakeStore{
+	app := newTestApp(&fakeStore{
 		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
 			return store.SearchResult{}, defaultErr
 		},
@@ -139,3 +365,38 @@ func TestRoute_Search_SearcherError(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 }
+
+func TestRoute_Versions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		url       string
+		successor string // of a deprecated endpoint
+	}{
+		{name: "v1", url: "/api/v1/works/1"},
+		{name: "legacy", url: "/works/1", successor: "/api/v1/works/1"},
+		{name: "graphql", url: "/api/v1/graphql?query=%7Btitles%7Btitle%7D%7D"},
+		{name: "legacy graphql", url: "/graphql?query=%7Btitles%7Btitle%7D%7D", successor: "/api/v1/graphql"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			if tc.successor != "" {
+				assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+				assert.Equal(t, legacySunset, resp.Header.Get("Sunset"))
+				assert.Equal(t, "<"+tc.successor+`>; rel="successor-version"`, resp.Header.Get("Link"))
+			} else {
+				assert.Empty(t, resp.Header.Get("Deprecation"))
+				assert.Empty(t, resp.Header.Get("Sunset"))
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/render"
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// characterOptions represents the query params of the character endpoints
+type characterOptions struct {
+	Edition string `query:"edition"`
+}
+
+func charactersHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var options characterOptions
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		id := c.Params("id")
+		characters, err := s.Characters(id, options.Edition)
+		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("work not found: %s", id))
+			}
+			return err
+		}
+		return c.JSON(characters)
+	}
+}
+
+func characterHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var options characterOptions
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		id := c.Params("id")
+		character, err := s.GetCharacter(id, options.Edition)
+		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) || errors.Is(err, store.ErrCharacterNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("character not found: %s", id))
+			}
+			return err
+		}
+		return c.JSON(character)
+	}
+}
+
+func characterStatsHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var options characterOptions
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		id := c.Params("id")
+		// names can have spaces, e.g. Lady Macbeth, which fiber leaves escaped
+		name, err := url.PathUnescape(c.Params("name"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid name: %s", c.Params("name")))
+		}
+		stats, err := s.CharacterStats(id, name, options.Edition)
+		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("work not found: %s", id))
+			}
+			if errors.Is(err, store.ErrCharacterNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("character not found: %s in %s", name, id))
+			}
+			return err
+		}
+		return c.JSON(stats)
+	}
+}
+
+// networkFormat represents a graph format networks can be exported in
+type networkFormat struct {
+	contentType string
+	render      func(w io.Writer, network store.Network) error
+}
+
+var networkFormats = map[string]networkFormat{
+	"graphml": {contentType: "application/graphml+xml; charset=utf-8", render: render.GraphML},
+	"gexf":    {contentType: "application/gexf+xml; charset=utf-8", render: render.GEXF},
+}
+
+// networkOptions represents the query params of the network endpoint
+type networkOptions struct {
+	Edition string `query:"edition"`
+	Format  string `query:"format"`
+}
+
+func networkHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := networkOptions{
+			Format: "json",
+		}
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if _, ok := networkFormats[options.Format]; !ok && options.Format != "json" {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid format: %s", options.Format))
+		}
+		id := c.Params("id")
+		network, err := s.Network(id, options.Edition)
+		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("work not found: %s", id))
+			}
+			return err
+		}
+		if options.Format == "json" {
+			return c.JSON(network)
+		}
+
+		nf := networkFormats[options.Format]
+		c.Set(fiber.HeaderContentType, nf.contentType)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, network.WorkID, options.Format))
+		return nf.render(c, network)
+	}
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestRoute_Characters(t *testing.T) {
+	testCases := []struct {
+		name           string
+		url            string
+		charactersFunc func(workID string, edition string) ([]store.Character, error)
+		characterFunc  func(id string, edition string) (store.Character, error)
+		statusCode     int
+	}{
+		{
+			name: "characters",
+			url:  "/api/v1/works/MACBETH/characters?edition=folger",
+			charactersFunc: func(workID string, edition string) ([]store.Character, error) {
+				assert.Equal(t, "MACBETH", workID)
+				assert.Equal(t, "folger", edition)
+				return []store.Character{{ID: "MACBETH.macbeth"}}, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "work not found",
+			url:  "/api/v1/works/MACBETH/characters",
+			charactersFunc: func(workID string, edition string) ([]store.Character, error) {
+				return nil, store.ErrWorkNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "character",
+			url:  "/api/v1/characters/MACBETH.lady-macbeth",
+			characterFunc: func(id string, edition string) (store.Character, error) {
+				assert.Equal(t, "MACBETH.lady-macbeth", id)
+				assert.Equal(t, "", edition)
+				return store.Character{ID: id}, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "character not found",
+			url:  "/api/v1/characters/MACBETH.hamlet",
+			characterFunc: func(id string, edition string) (store.Character, error) {
+				return store.Character{}, store.ErrCharacterNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error",
+			url:  "/api/v1/characters/MACBETH.macbeth",
+			characterFunc: func(id string, edition string) (store.Character, error) {
+				return store.Character{}, defaultErr
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{charactersFunc: tc.charactersFunc, characterFunc: tc.characterFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoute_Network(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		networkFunc func(workID string, edition string) (store.Network, error)
+		statusCode  int
+		contentType string
+	}{
+		{
+			name: "json",
+			url:  "/api/v1/works/MACBETH/network?edition=folger",
+			networkFunc: func(workID string, edition string) (store.Network, error) {
+				assert.Equal(t, "MACBETH", workID)
+				assert.Equal(t, "folger", edition)
+				return store.Network{WorkID: workID}, nil
+			},
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+		},
+		{
+			name:        "graphml",
+			url:         "/api/v1/works/MACBETH/network?format=graphml",
+			statusCode:  http.StatusOK,
+			contentType: "application/graphml+xml; charset=utf-8",
+		},
+		{
+			name:        "gexf",
+			url:         "/api/v1/works/MACBETH/network?format=gexf",
+			statusCode:  http.StatusOK,
+			contentType: "application/gexf+xml; charset=utf-8",
+		},
+		{
+			name:       "invalid format",
+			url:        "/api/v1/works/MACBETH/network?format=dot",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "work not found",
+			url:  "/api/v1/works/MACBETH/network",
+			networkFunc: func(workID string, edition string) (store.Network, error) {
+				return store.Network{}, store.ErrWorkNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{networkFunc: tc.networkFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+			if tc.contentType != "" {
+				assert.Equal(t, tc.contentType, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRoute_CharacterStats(t *testing.T) {
+	testCases := []struct {
+		name          string
+		url           string
+		charStatsFunc func(workID string, name string, edition string) (store.CharacterStats, error)
+		statusCode    int
+	}{
+		{
+			name: "stats",
+			url:  "/api/v1/works/MACBETH/characters/LADY%20M./stats?edition=folger",
+			charStatsFunc: func(workID string, name string, edition string) (store.CharacterStats, error) {
+				assert.Equal(t, "MACBETH", workID)
+				assert.Equal(t, "LADY M.", name)
+				assert.Equal(t, "folger", edition)
+				return store.CharacterStats{}, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "work not found",
+			url:  "/api/v1/works/MACBETH/characters/macbeth/stats",
+			charStatsFunc: func(workID string, name string, edition string) (store.CharacterStats, error) {
+				return store.CharacterStats{}, store.ErrWorkNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "character not found",
+			url:  "/api/v1/works/MACBETH/characters/hamlet/stats",
+			charStatsFunc: func(workID string, name string, edition string) (store.CharacterStats, error) {
+				return store.CharacterStats{}, store.ErrCharacterNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{charStatsFunc: tc.charStatsFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
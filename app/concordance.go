@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const (
+	defaultConcordanceWidth = 40
+	maxConcordanceWidth     = 200
+)
+
+// delimitedFormat represents a delimiter-separated export format
+type delimitedFormat struct {
+	comma       rune
+	contentType string
+}
+
+var delimitedFormats = map[string]delimitedFormat{
+	"csv": {comma: ',', contentType: "text/csv; charset=utf-8"},
+	"tsv": {comma: '\t', contentType: "text/tab-separated-values; charset=utf-8"},
+}
+
+func writeConcordance(c *fiber.Ctx, concordance store.Concordance, format string) error {
+	c.Set(fiber.HeaderContentType, delimitedFormats[format].contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="concordance.%s"`, format))
+
+	w := csv.NewWriter(c)
+	w.Comma = delimitedFormats[format].comma
+	if err := w.Write([]string{"workId", "title", "lineNumber", "left", "keyword", "right"}); err != nil {
+		return err
+	}
+	for _, line := range concordance.Data {
+		record := []string{
+			line.WorkID,
+			line.Title,
+			strconv.Itoa(line.LineNumber),
+			line.Left,
+			line.Keyword,
+			line.Right,
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func concordanceHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := store.ConcordanceOptions{
+			Width: defaultConcordanceWidth,
+		}
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if options.Term == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "term is required")
+		}
+		if options.Width < 1 || options.Width > maxConcordanceWidth {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("width must be between 1 and %d", maxConcordanceWidth))
+		}
+		if options.Sort != "" && options.Sort != "left" && options.Sort != "right" {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid sort: %s", options.Sort))
+		}
+		format := c.Query("format", "json")
+		if _, ok := delimitedFormats[format]; !ok && format != "json" {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid format: %s", format))
+		}
+
+		concordance, err := s.Concordance(options)
+		if err != nil {
+			return err
+		}
+		if format == "json" {
+			return c.JSON(concordance)
+		}
+		return writeConcordance(c, concordance, format)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestRoute_Concordance_InvalidQueryParams(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+	}{
+		{name: "missing term", url: "/concordance"},
+		{name: "width too large", url: "/concordance?term=blood&width=1000"},
+		{name: "invalid sort", url: "/concordance?term=blood&sort=middle"},
+		{name: "invalid format", url: "/concordance?term=blood&format=xlsx"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoute_Concordance_Export(t *testing.T) {
+	var got store.ConcordanceOptions
+	app := newTestApp(&fakeStore{
+		concordanceFunc: func(options store.ConcordanceOptions) (store.Concordance, error) {
+			got = options
+			return store.Concordance{
+				Data: []store.ConcordanceLine{
+					{Keyword: "blood", Left: "will have ", LineNumber: 1, Right: "", Title: "MACBETH", WorkID: "MACBETH"},
+				},
+			}, nil
+		},
+	})
+
+	testCases := []struct {
+		format      string
+		contentType string
+		body        string
+	}{
+		{
+			format:      "csv",
+			contentType: "text/csv; charset=utf-8",
+			body:        "workId,title,lineNumber,left,keyword,right\nMACBETH,MACBETH,1,will have ,blood,\n",
+		},
+		{
+			format:      "tsv",
+			contentType: "text/tab-separated-values; charset=utf-8",
+			body:        "workId\ttitle\tlineNumber\tleft\tkeyword\tright\nMACBETH\tMACBETH\t1\twill have \tblood\t\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/concordance?term=blood&sort=left&format="+tc.format, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.contentType, resp.Header.Get("Content-Type"))
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.body, string(body))
+			assert.Equal(t, store.ConcordanceOptions{Term: "blood", Width: 40, Sort: "left"}, got)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// diffOptions represents the query params of the diff endpoint
+type diffOptions struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+func diffHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := diffOptions{
+			From: store.DefaultEdition,
+		}
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if options.To == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "to is required")
+		}
+
+		id := c.Params("id")
+		diff, err := s.Diff(id, options.From, options.To)
+		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("work not found: %s in editions %s and %s", id, options.From, options.To))
+			}
+			return err
+		}
+		return c.JSON(diff)
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestRoute_Diff(t *testing.T) {
+	testCases := []struct {
+		name       string
+		url        string
+		diffFunc   func(id string, from string, to string) (store.Diff, error)
+		statusCode int
+	}{
+		{
+			name: "default from",
+			url:  "/api/v1/works/MACBETH/diff?to=folio",
+			diffFunc: func(id string, from string, to string) (store.Diff, error) {
+				assert.Equal(t, "MACBETH", id)
+				assert.Equal(t, store.DefaultEdition, from)
+				assert.Equal(t, "folio", to)
+				return store.Diff{}, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "missing to",
+			url:        "/api/v1/works/MACBETH/diff?from=folio",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "work not found",
+			url:  "/api/v1/works/MACBETH/diff?from=folio&to=quarto",
+			diffFunc: func(id string, from string, to string) (store.Diff, error) {
+				return store.Diff{}, store.ErrWorkNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{diffFunc: tc.diffFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const (
+	// exportFlushSize is the number of hits written before the response is flushed
+	exportFlushSize = 100
+	// defaultMaxExportResults is the default maximum number of hits of an export
+	defaultMaxExportResults = 100000
+)
+
+// errExportLimit stops the scan of an export once the maximum number of hits is written
+var errExportLimit = errors.New("export limit reached")
+
+// exportFormats are the content types of the export formats
+var exportFormats = map[string]string{
+	"ndjson": "application/x-ndjson",
+	"csv":    "text/csv; charset=utf-8",
+}
+
+// hitWriter writes hits in an export format
+type hitWriter interface {
+	Write(hit store.Hit) error
+	Flush() error
+}
+
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(hit store.Hit) error {
+	return n.enc.Encode(hit)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
+
+type csvWriter struct {
+	w   *bufio.Writer
+	csv *csv.Writer
+}
+
+func (c *csvWriter) Write(hit store.Hit) error {
+	return c.csv.Write([]string{
+		hit.WorkID,
+		hit.Title,
+		strconv.Itoa(hit.LineNumber),
+		strconv.FormatFloat(hit.Score, 'f', -1, 64),
+		hit.Line,
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func newHitWriter(w *bufio.Writer, format string) (hitWriter, error) {
+	if format == "csv" {
+		c := &csvWriter{w: w, csv: csv.NewWriter(w)}
+		return c, c.csv.Write([]string{"workId", "title", "lineNumber", "score", "line"})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // keeps the highlight tags readable
+	return &ndjsonWriter{w: w, enc: enc}, nil
+}
+
+// exportHits writes the hits of a scan, flushing every exportFlushSize hits. A flush
+// blocks until the client has read enough of the response, which holds back the scan.
+func exportHits(s Store, options store.SearchOptions, w hitWriter, limit int) error {
+	count := 0
+	err := s.Scan(options, func(hit store.Hit) error {
+		if count >= limit {
+			return errExportLimit
+		}
+		if err := w.Write(hit); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushSize == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errExportLimit) {
+		return err
+	}
+	return w.Flush()
+}
+
+// countHits returns the meta of a search for the options, with the number of hits
+func countHits(s Store, options store.SearchOptions) (store.Meta, error) {
+	options.PageNumber = 1
+	options.PageSize = 1
+	options.Autocorrect = false
+	result, err := s.Search(options)
+	return result.Meta, err
+}
+
+// exportHandler streams every hit of a search, up to maxResults, as NDJSON or CSV.
+// The total number of matching lines is sent in the X-Total-Results header, with
+// X-Results-Truncated if the store stopped counting them.
+func exportHandler(s Store, maxResults int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := newSearchOptions()
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if err := validateSearchOptions(options); err != nil {
+			return err
+		}
+		format := c.Query("format", "ndjson")
+		contentType, ok := exportFormats[format]
+		if !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid format: %s", format))
+		}
+
+		// counts the hits first so that invalid queries are reported before the response starts
+		countResult, err := countHits(s, options)
+		if err != nil {
+			return searchError(err)
+		}
+
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="search.%s"`, format))
+		c.Set("X-Total-Results", strconv.Itoa(countResult.TotalResults))
+		if countResult.Truncated {
+			c.Set("X-Results-Truncated", "true")
+		}
+		c.Set("X-Export-Limit", strconv.Itoa(maxResults))
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			hits, err := newHitWriter(w, format)
+			if err == nil {
+				err = exportHits(s, options, hits, maxResults)
+			}
+			if err != nil {
+				// the status is already sent, so the error can only end the response early
+				log.Errorf("Failed to export search results: %v", err)
+			}
+		})
+		return nil
+	}
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func newExportTestStore(total int) *fakeStore {
+	return &fakeStore{
+		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+			return store.SearchResult{Meta: store.Meta{TotalResults: total}}, nil
+		},
+		scanFunc: func(options store.SearchOptions, fn func(store.Hit) error) error {
+			for i := 1; i <= total; i++ {
+				hit := store.Hit{Line: "a, <mark>b</mark>", LineNumber: i, Score: 0.5, Title: "T", WorkID: "W"}
+				if err := fn(hit); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestRoute_SearchExport(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		total       int
+		maxResults  int
+		contentType string
+		expected    string
+	}{
+		{
+			name:        "ndjson",
+			url:         "/api/v1/search/export?q=b",
+			total:       2,
+			maxResults:  10,
+			contentType: "application/x-ndjson",
+			expected: `{"line":"a, <mark>b</mark>","lineNumber":1,"score":0.5,"title":"T","workId":"W"}
+{"line":"a, <mark>b</mark>","lineNumber":2,"score":0.5,"title":"T","workId":"W"}
+`,
+		},
+		{
+			name:        "csv",
+			url:         "/api/v1/search/export?q=b&format=csv",
+			total:       1,
+			maxResults:  10,
+			contentType: "text/csv; charset=utf-8",
+			expected:    "workId,title,lineNumber,score,line\nW,T,1,0.5,\"a, <mark>b</mark>\"\n",
+		},
+		{
+			name:        "limited",
+			url:         "/api/v1/search/export?q=b&format=csv",
+			total:       5,
+			maxResults:  2,
+			contentType: "text/csv; charset=utf-8",
+			expected:    "workId,title,lineNumber,score,line\nW,T,1,0.5,\"a, <mark>b</mark>\"\nW,T,2,0.5,\"a, <mark>b</mark>\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newFiberApp(newExportTestStore(tc.total), Config{MaxExportResults: tc.maxResults})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.contentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, strconv.Itoa(tc.total), resp.Header.Get("X-Total-Results"))
+			byt, err := ioutil.ReadAll(resp.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, string(byt))
+		})
+	}
+}
+
+func TestRoute_SearchExport_Truncated(t *testing.T) {
+	s := newExportTestStore(2)
+	s.searchFunc = func(options store.SearchOptions) (store.SearchResult, error) {
+		return store.SearchResult{Meta: store.Meta{TotalResults: 2, Truncated: true}}, nil
+	}
+	app := newFiberApp(s, Config{MaxExportResults: 10})
+	req, err := http.NewRequest("GET", "/api/v1/search/export?regex=b", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("X-Total-Results"))
+	assert.Equal(t, "true", resp.Header.Get("X-Results-Truncated"))
+}
+
+func TestRoute_SearchExport_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		url        string
+		searchFunc func(store.SearchOptions) (store.SearchResult, error)
+		statusCode int
+	}{
+		{
+			name:       "invalid format",
+			url:        "/api/v1/search/export?q=b&format=xml",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid options",
+			url:        "/api/v1/search/export?fuzziness=5",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "searcher error",
+			url:  "/api/v1/search/export?q=b",
+			searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+				return store.SearchResult{}, defaultErr
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{searchFunc: tc.searchFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,578 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const (
+	// graphqlPath is the path the GraphQL endpoint is served at
+	graphqlPath = "/graphql"
+	// maxQueryDepth is the maximum nesting of fields in a GraphQL query
+	maxQueryDepth = 6
+	// maxQueryComplexity is the maximum cost of a GraphQL query. Each field costs 1
+	// and the fields selected under a list cost as many times more as the items
+	// expected in the list: the page size of a search for its hits, the number of
+	// lines asked for, or listComplexityFactor for other lists. The content of a work,
+	// or all its lines, cost workComplexity.
+	maxQueryComplexity   = 5000
+	listComplexityFactor = 10
+	workComplexity       = 200
+	// defaultContextLines and maxContextLines are the number of lines around a hit
+	defaultContextLines = 2
+	maxContextLines     = 20
+)
+
+// graphqlRequest represents a GraphQL request sent as query params or a JSON body
+type graphqlRequest struct {
+	Query         string                 `query:"query" json:"query"`
+	OperationName string                 `query:"operationName" json:"operationName"`
+	Variables     map[string]interface{} `query:"-" json:"variables"`
+}
+
+// workLoaderKey is the context key of the workLoader of a request
+type workLoaderKey struct{}
+
+// workLoader keeps the works loaded while resolving a request so that hits from the
+// same work share a single copy of it and of its lines
+type workLoader struct {
+	s     Store
+	mu    sync.Mutex
+	works map[string]store.ShakespeareWork
+	lines map[string][]store.Line
+}
+
+func newWorkLoader(s Store) *workLoader {
+	return &workLoader{
+		s:     s,
+		works: make(map[string]store.ShakespeareWork),
+		lines: make(map[string][]store.Line),
+	}
+}
+
+func (l *workLoader) work(workID string, edition string) (store.ShakespeareWork, error) {
+	key := edition + "/" + workID
+	if work, ok := l.works[key]; ok {
+		return work, nil
+	}
+	work, err := getWork(l.s, workID, edition)
+	if err != nil {
+		return work, err
+	}
+	l.works[key] = work
+	return work, nil
+}
+
+func (l *workLoader) Work(workID string, edition string) (store.ShakespeareWork, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.work(workID, edition)
+}
+
+func (l *workLoader) Lines(workID string, edition string) ([]store.Line, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := edition + "/" + workID
+	if lines, ok := l.lines[key]; ok {
+		return lines, nil
+	}
+	work, err := l.work(workID, edition)
+	if err != nil {
+		return nil, err
+	}
+	lines := work.Lines()
+	l.lines[key] = lines
+	return lines, nil
+}
+
+// graphqlError converts an error of the store to the error returned to users
+func graphqlError(err error) error {
+	return toFiberError(searchError(err))
+}
+
+func intArg(args map[string]interface{}, name string, defaultValue int) int {
+	if v, ok := args[name].(int); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	v, _ := args[name].(string)
+	return v
+}
+
+// linesBetween returns the lines numbered from from to to, where a zero bound is unbounded
+func linesBetween(lines []store.Line, from, to int) []store.Line {
+	selected := make([]store.Line, 0)
+	for _, line := range lines {
+		if (from > 0 && line.LineNumber < from) || (to > 0 && line.LineNumber > to) {
+			continue
+		}
+		selected = append(selected, line)
+	}
+	return selected
+}
+
+// linesAround returns the line numbered lineNumber with up to before lines before it and after lines after it
+func linesAround(lines []store.Line, lineNumber, before, after int) []store.Line {
+	i := sort.Search(len(lines), func(i int) bool {
+		return lines[i].LineNumber >= lineNumber
+	})
+	if i == len(lines) || lines[i].LineNumber != lineNumber {
+		return make([]store.Line, 0)
+	}
+	start := i - before
+	if start < 0 {
+		start = 0
+	}
+	end := i + after + 1
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return lines[start:end]
+}
+
+func searchArgs(args map[string]interface{}) (store.SearchOptions, error) {
+	options := newSearchOptions()
+	options.Query = stringArg(args, "q")
+	options.Fuzziness = intArg(args, "fuzziness", options.Fuzziness)
+	options.WorkID = stringArg(args, "workId")
+	options.Edition = stringArg(args, "edition")
+	options.Mode = stringArg(args, "mode")
+	options.Regex = stringArg(args, "regex")
+	options.Form = stringArg(args, "form")
+	options.In = stringArg(args, "in")
+	options.Meter = stringArg(args, "meter")
+	options.Rhyme = stringArg(args, "rhyme")
+	options.PageNumber = intArg(args, "page", options.PageNumber)
+	options.PageSize = intArg(args, "pageSize", options.PageSize)
+	if autocorrect, ok := args["autocorrect"].(bool); ok {
+		options.Autocorrect = autocorrect
+	}
+	if sortBy, ok := args["sortBy"].([]interface{}); ok {
+		options.SortBy = nil
+		for _, field := range sortBy {
+			options.SortBy = append(options.SortBy, field.(string))
+		}
+	}
+	return options, validateSearchOptions(options)
+}
+
+func newGraphQLSchema(s Store) (graphql.Schema, error) {
+	lineType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Line",
+		Description: "A non-empty line of a work",
+		Fields: graphql.Fields{
+			"lineNumber": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"text":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	lineListType := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(lineType)))
+
+	workType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Work",
+		Description: "A work of Shakespeare (poem, play, sonnet, ...)",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"edition": &graphql.Field{Type: graphql.String, Description: "Text the work is read from"},
+			"title":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"year":    &graphql.Field{Type: graphql.Int, Description: "Year of composition"},
+			"content": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"lines": &graphql.Field{
+				Type:        lineListType,
+				Description: "Lines numbered from from to to (inclusive), or every line without bounds",
+				Args: graphql.FieldConfigArgument{
+					"from": &graphql.ArgumentConfig{Type: graphql.Int},
+					"to":   &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					work := p.Source.(store.ShakespeareWork)
+					return linesBetween(work.Lines(), intArg(p.Args, "from", 0), intArg(p.Args, "to", 0)), nil
+				},
+			},
+		},
+	})
+
+	hitType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Hit",
+		Description: "A line matching a search",
+		Fields: graphql.Fields{
+			"edition":    &graphql.Field{Type: graphql.String},
+			"line":       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "The line with the matches highlighted"},
+			"lineNumber": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"score":      &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"title":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"workId":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"work": &graphql.Field{
+				Type: graphql.NewNonNull(workType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					hit := p.Source.(store.Hit)
+					work, err := p.Context.Value(workLoaderKey{}).(*workLoader).Work(hit.WorkID, hit.Edition)
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					return work, nil
+				},
+			},
+			"context": &graphql.Field{
+				Type:        lineListType,
+				Description: fmt.Sprintf("The line with the lines around it (at most %d on each side)", maxContextLines),
+				Args: graphql.FieldConfigArgument{
+					"before": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultContextLines},
+					"after":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultContextLines},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					before := intArg(p.Args, "before", defaultContextLines)
+					after := intArg(p.Args, "after", defaultContextLines)
+					if before < 0 || before > maxContextLines || after < 0 || after > maxContextLines {
+						return nil, fmt.Errorf("before and after must be between 0 and %d", maxContextLines)
+					}
+					hit := p.Source.(store.Hit)
+					lines, err := p.Context.Value(workLoaderKey{}).(*workLoader).Lines(hit.WorkID, hit.Edition)
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					return linesAround(lines, hit.LineNumber, before, after), nil
+				},
+			},
+		},
+	})
+
+	suggestionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Suggestion",
+		Fields: graphql.Fields{
+			"term":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"suggestion": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	metaType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Meta",
+		Fields: graphql.Fields{
+			"correctedQuery": &graphql.Field{Type: graphql.String},
+			"pageNumber":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"pageSize":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"suggestions":    &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(suggestionType))},
+			"totalResults":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"truncated":      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	searchResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SearchResult",
+		Fields: graphql.Fields{
+			"data": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(hitType)))},
+			"meta": &graphql.Field{Type: graphql.NewNonNull(metaType)},
+		},
+	})
+
+	titleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Title",
+		Fields: graphql.Fields{
+			"editions": &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"title":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"workId":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"titles": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(titleType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.ListTitles(), nil
+				},
+			},
+			"work": &graphql.Field{
+				Type:        workType,
+				Description: "The work with the id in the edition (by default the store's), or null if there is none",
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"edition": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					work, err := getWork(s, stringArg(p.Args, "id"), stringArg(p.Args, "edition"))
+					if err != nil {
+						if errors.Is(err, store.ErrWorkNotFound) {
+							return nil, nil
+						}
+						return nil, graphqlError(err)
+					}
+					return work, nil
+				},
+			},
+			"search": &graphql.Field{
+				Type:        graphql.NewNonNull(searchResultType),
+				Description: "Search lines of Shakespeare's works, with the same options as GET /api/v1/search",
+				Args: graphql.FieldConfigArgument{
+					"q":           &graphql.ArgumentConfig{Type: graphql.String},
+					"fuzziness":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"workId":      &graphql.ArgumentConfig{Type: graphql.String},
+					"edition":     &graphql.ArgumentConfig{Type: graphql.String},
+					"page":        &graphql.ArgumentConfig{Type: graphql.Int},
+					"pageSize":    &graphql.ArgumentConfig{Type: graphql.Int},
+					"sortBy":      &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"autocorrect": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"mode":        &graphql.ArgumentConfig{Type: graphql.String},
+					"regex":       &graphql.ArgumentConfig{Type: graphql.String},
+					"form":        &graphql.ArgumentConfig{Type: graphql.String},
+					"in":          &graphql.ArgumentConfig{Type: graphql.String},
+					"meter":       &graphql.ArgumentConfig{Type: graphql.String},
+					"rhyme":       &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					options, err := searchArgs(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					searchResult, err := s.Search(options)
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					return searchResult, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// queryCost computes the depth and complexity of a query before it is executed
+type queryCost struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// intArgument returns the value of an int argument of a field, given as a literal or a
+// variable, or defaultValue if it is not set
+func (q *queryCost) intArgument(field *ast.Field, name string, defaultValue int) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				return n
+			}
+		case *ast.Variable:
+			switch n := q.variables[v.Name.Value].(type) {
+			case int:
+				return n
+			case float64: // variables decoded from JSON
+				return int(n)
+			}
+		}
+	}
+	return defaultValue
+}
+
+// listSize returns the number of items expected in the list returned by a field of an
+// object. pageSize is the page size of the search the object is the result of.
+func (q *queryCost) listSize(object *graphql.Object, field *ast.Field, pageSize int) int {
+	switch object.Name() + "." + field.Name.Value {
+	case "SearchResult.data":
+		return pageSize
+	case "Hit.context":
+		return q.intArgument(field, "before", defaultContextLines) + q.intArgument(field, "after", defaultContextLines) + 1
+	case "Work.lines":
+		from, to := q.intArgument(field, "from", 0), q.intArgument(field, "to", 0)
+		if from > 0 && to >= from && to-from+1 < workComplexity {
+			return to - from + 1
+		}
+		return workComplexity
+	}
+	return listComplexityFactor
+}
+
+// selectionSet returns the depth and complexity of the selections on a type.
+// Introspection fields are not counted as their size only depends on the schema.
+func (q *queryCost) selectionSet(selectionSet *ast.SelectionSet, parent graphql.Type, pageSize int) (int, int) {
+	if selectionSet == nil {
+		return 0, 0
+	}
+	depth, complexity := 0, 0
+	add := func(d, c int) {
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+	for _, selection := range selectionSet.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			d, c := q.field(sel, parent, pageSize)
+			add(d, c)
+		case *ast.InlineFragment:
+			typ := parent
+			if sel.TypeCondition != nil {
+				typ = q.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			add(q.selectionSet(sel.SelectionSet, typ, pageSize))
+		case *ast.FragmentSpread:
+			// fragment cycles are rejected by the validation
+			if fragment, ok := q.fragments[sel.Name.Value]; ok {
+				add(q.selectionSet(fragment.SelectionSet, q.schema.Type(fragment.TypeCondition.Name.Value), pageSize))
+			}
+		}
+	}
+	return depth, complexity
+}
+
+func (q *queryCost) field(field *ast.Field, parent graphql.Type, pageSize int) (int, int) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return 0, 0
+	}
+	object, ok := parent.(*graphql.Object)
+	if !ok {
+		return 0, 0
+	}
+	def, ok := object.Fields()[name]
+	if !ok {
+		return 0, 0
+	}
+	if object == q.schema.QueryType() && name == "search" {
+		pageSize = q.intArgument(field, "pageSize", newSearchOptions().PageSize)
+		if pageSize < 0 {
+			pageSize = 0
+		}
+	}
+	cost := 1
+	if object.Name() == "Work" && name == "content" {
+		cost = workComplexity
+	}
+
+	factor := 1
+	typ := def.Type
+	for {
+		if nonNull, ok := typ.(*graphql.NonNull); ok {
+			typ = nonNull.OfType
+			continue
+		}
+		if list, ok := typ.(*graphql.List); ok {
+			factor *= q.listSize(object, field, pageSize)
+			typ = list.OfType
+			continue
+		}
+		break
+	}
+	depth, complexity := q.selectionSet(field.SelectionSet, typ, pageSize)
+	return depth + 1, cost + factor*complexity
+}
+
+// checkQueryCost returns an error if an operation of the document is nested deeper than maxDepth
+// or is more complex than maxComplexity with the variables of the request
+func checkQueryCost(schema graphql.Schema, document *ast.Document, variables map[string]interface{}, maxDepth, maxComplexity int) error {
+	cost := &queryCost{schema: schema, fragments: make(map[string]*ast.FragmentDefinition)}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			cost.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		// variables not sent have the default value of their definition
+		cost.variables = make(map[string]interface{})
+		for _, variable := range operation.VariableDefinitions {
+			if value, ok := variable.DefaultValue.(*ast.IntValue); ok {
+				if n, err := strconv.Atoi(value.Value); err == nil {
+					cost.variables[variable.Variable.Name.Value] = n
+				}
+			}
+		}
+		for name, value := range variables {
+			cost.variables[name] = value
+		}
+		depth, complexity := cost.selectionSet(operation.SelectionSet, schema.QueryType(), 0)
+		if depth > maxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, maxDepth)
+		}
+		if complexity > maxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, maxComplexity)
+		}
+	}
+	return nil
+}
+
+// parseGraphQL parses and validates a request, returning the errors rejecting it
+func parseGraphQL(schema graphql.Schema, req graphqlRequest) (*ast.Document, []gqlerrors.FormattedError) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	validation := graphql.ValidateDocument(&schema, document, nil)
+	if !validation.IsValid {
+		return nil, validation.Errors
+	}
+	if err := checkQueryCost(schema, document, req.Variables, maxQueryDepth, maxQueryComplexity); err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	return document, nil
+}
+
+// graphqlHandler serves GraphQL queries sent as query params (variables as a JSON string)
+// or as a JSON body
+func graphqlHandler(s Store) fiber.Handler {
+	schema, err := newGraphQLSchema(s)
+	if err != nil {
+		panic(err)
+	}
+	return func(c *fiber.Ctx) error {
+		var req graphqlRequest
+		if c.Method() == fiber.MethodPost {
+			if err := json.Unmarshal(c.Body(), &req); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+		} else {
+			if err := c.QueryParser(&req); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "variables must be a JSON object")
+				}
+			}
+		}
+		if req.Query == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "query is required")
+		}
+
+		document, errs := parseGraphQL(schema, req)
+		if len(errs) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(graphql.Result{Errors: errs})
+		}
+		loader := newWorkLoader(s)
+		return c.JSON(graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           document,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       context.WithValue(c.Context(), workLoaderKey{}, loader),
+		}))
+	}
+}
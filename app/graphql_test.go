@@ -0,0 +1,269 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func newGraphQLTestStore() *fakeStore {
+	return &fakeStore{
+		getWorkByIDFunc: func(id string) (store.ShakespeareWork, error) {
+			if id != "MACBETH" {
+				return store.ShakespeareWork{}, store.ErrWorkNotFound
+			}
+			return store.ShakespeareWork{
+				ID:      "MACBETH",
+				Title:   "MACBETH",
+				Content: "one\n\ntwo\n\nthree\n\nfour\n\nfive",
+			}, nil
+		},
+		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+			return store.SearchResult{
+				Data: []store.Hit{{Line: "<mark>three</mark>", LineNumber: 5, Title: "MACBETH", WorkID: "MACBETH"}},
+				Meta: store.Meta{PageNumber: options.PageNumber, PageSize: options.PageSize, TotalResults: 1},
+			}, nil
+		},
+	}
+}
+
+func postGraphQL(t *testing.T, body string) (int, map[string]interface{}) {
+	app := newTestApp(newGraphQLTestStore())
+	req, err := http.NewRequest("POST", apiV1Prefix+graphqlPath, bytes.NewBufferString(body))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+
+	byt, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	var result map[string]interface{}
+	assert.Nil(t, json.Unmarshal(byt, &result))
+	return resp.StatusCode, result
+}
+
+func TestRoute_GraphQL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "work with lines",
+			query:    `{ work(id: "MACBETH") { title lines(from: 3, to: 5) { lineNumber text } } }`,
+			expected: `{"data":{"work":{"lines":[{"lineNumber":3,"text":"two"},{"lineNumber":5,"text":"three"}],"title":"MACBETH"}}}`,
+		},
+		{
+			name:     "work not found",
+			query:    `{ work(id: "HAMLET") { title } }`,
+			expected: `{"data":{"work":null}}`,
+		},
+		{
+			name:     "search hits with context",
+			query:    `{ search(q: "three", pageSize: 5) { data { lineNumber context(before: 1, after: 1) { text } } meta { pageSize totalResults truncated } } }`,
+			expected: `{"data":{"search":{"data":[{"context":[{"text":"two"},{"text":"three"},{"text":"four"}],"lineNumber":5}],"meta":{"pageSize":5,"totalResults":1,"truncated":false}}}}`,
+		},
+		{
+			name:     "context at the start of a work",
+			query:    `{ search(q: "three") { data { context(before: 20, after: 0) { lineNumber } } } }`,
+			expected: `{"data":{"search":{"data":[{"context":[{"lineNumber":1},{"lineNumber":3},{"lineNumber":5}]}]}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := json.Marshal(graphqlRequest{Query: tc.query})
+			assert.Nil(t, err)
+			statusCode, result := postGraphQL(t, string(body))
+			assert.Equal(t, http.StatusOK, statusCode)
+
+			byt, err := json.Marshal(result)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, string(byt))
+		})
+	}
+}
+
+func TestRoute_GraphQL_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		query      string
+		statusCode int
+		message    string
+	}{
+		{
+			name:       "syntax error",
+			query:      `{ work(id: "MACBETH") {`,
+			statusCode: http.StatusBadRequest,
+			message:    "Syntax Error",
+		},
+		{
+			name:       "unknown field",
+			query:      `{ work(id: "MACBETH") { author } }`,
+			statusCode: http.StatusBadRequest,
+			message:    `Cannot query field "author"`,
+		},
+		{
+			name:       "too complex",
+			query:      `{ search(pageSize: 1000) { data { work { content lines { text } } } } }`,
+			statusCode: http.StatusBadRequest,
+			message:    "query complexity",
+		},
+		{
+			name:       "invalid search options",
+			query:      `{ search(pageSize: 5000) { meta { totalResults } } }`,
+			statusCode: http.StatusOK,
+			message:    "page[size] must be between 1 and 1000",
+		},
+		{
+			name:       "invalid context",
+			query:      `{ search { data { context(before: 100) { text } } } }`,
+			statusCode: http.StatusOK,
+			message:    "before and after must be between 0 and 20",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := json.Marshal(graphqlRequest{Query: tc.query})
+			assert.Nil(t, err)
+			statusCode, result := postGraphQL(t, string(body))
+			assert.Equal(t, tc.statusCode, statusCode)
+			if tc.message == "" {
+				assert.NotContains(t, result, "errors")
+				return
+			}
+			errs, ok := result["errors"].([]interface{})
+			assert.True(t, ok)
+			assert.Contains(t, errs[0].(map[string]interface{})["message"], tc.message)
+		})
+	}
+}
+
+func TestRoute_GraphQL_WorkLoader(t *testing.T) {
+	s := newGraphQLTestStore()
+	getWorkByID := s.getWorkByIDFunc
+	loads := 0
+	s.getWorkByIDFunc = func(id string) (store.ShakespeareWork, error) {
+		loads++
+		return getWorkByID(id)
+	}
+	s.searchFunc = func(options store.SearchOptions) (store.SearchResult, error) {
+		hit := store.Hit{Line: "three", LineNumber: 5, Title: "MACBETH", WorkID: "MACBETH"}
+		return store.SearchResult{Data: []store.Hit{hit, hit, hit}}, nil
+	}
+	app := newTestApp(s)
+	body, err := json.Marshal(graphqlRequest{Query: `{ search(q: "three") { data { work { title } context { text } } } }`})
+	assert.Nil(t, err)
+	req, err := http.NewRequest("POST", apiV1Prefix+graphqlPath, bytes.NewBuffer(body))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, loads)
+}
+
+func TestRoute_GraphQL_Get(t *testing.T) {
+	app := newTestApp(newGraphQLTestStore())
+	params := url.Values{}
+	params.Set("query", `query Work($id: String!) { work(id: $id) { id } }`)
+	params.Set("variables", `{"id": "MACBETH"}`)
+	req, err := http.NewRequest("GET", apiV1Prefix+graphqlPath+"?"+params.Encode(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	byt, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"data":{"work":{"id":"MACBETH"}}}`, strings.TrimSpace(string(byt)))
+}
+
+func TestCheckQueryCost(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		message   string
+	}{
+		{
+			name:  "within limits",
+			query: `{ work(id: "MACBETH") { lines(from: 1, to: 100) { text } } }`,
+		},
+		{
+			name:    "too deep",
+			query:   `{ search { data { work { lines { text } } } } }`,
+			message: "query depth 5 exceeds the maximum of 4",
+		},
+		{
+			name:    "too deep with fragments",
+			query:   `{ search { data { ...H } } } fragment H on Hit { work { ... on Work { lines { text } } } }`,
+			message: "query depth 5 exceeds the maximum of 4",
+		},
+		{
+			name:    "too complex",
+			query:   `{ search { data { a: context { text } b: context { text } } } }`,
+			message: "query complexity 242 exceeds the maximum of 200",
+		},
+		{
+			name:  "page size",
+			query: `{ search(pageSize: 100) { data { line } } }`,
+		},
+		{
+			name:      "page size variable",
+			query:     `query Search($size: Int) { search(pageSize: $size) { data { line } } }`,
+			variables: map[string]interface{}{"size": float64(1000)},
+			message:   "query complexity 1002 exceeds the maximum of 200",
+		},
+		{
+			name:    "page size variable default",
+			query:   `query Search($size: Int = 500) { search(pageSize: $size) { data { line } } }`,
+			message: "query complexity 502 exceeds the maximum of 200",
+		},
+		{
+			name:    "whole work",
+			query:   `{ work(id: "MACBETH") { content } }`,
+			message: "query complexity 201 exceeds the maximum of 200",
+		},
+		{
+			name:    "all lines",
+			query:   `{ work(id: "MACBETH") { lines { text } } }`,
+			message: "query complexity 202 exceeds the maximum of 200",
+		},
+		{
+			name:  "introspection is not counted",
+			query: `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`,
+		},
+	}
+
+	schema, err := newGraphQLSchema(&fakeStore{})
+	assert.Nil(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			document, errs := parseGraphQL(schema, graphqlRequest{Query: tc.query})
+			assert.Empty(t, errs)
+			err := checkQueryCost(schema, document, tc.variables, 4, 200)
+			if tc.message == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, tc.message)
+			}
+		})
+	}
+}
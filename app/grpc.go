@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/sankt-petersbug/shakesearch/rpc"
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// grpcCodes maps the status codes of the errors returned to users to gRPC codes
+var grpcCodes = map[int]codes.Code{
+	fiber.StatusBadRequest:          codes.InvalidArgument,
+	fiber.StatusNotFound:            codes.NotFound,
+	fiber.StatusUnprocessableEntity: codes.ResourceExhausted,
+}
+
+// grpcError converts an error to the gRPC status returned to users
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, store.ErrWorkNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	e := toFiberError(searchError(err))
+	code, ok := grpcCodes[e.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	return status.Error(code, e.Message)
+}
+
+// grpcServer implements the gRPC service over the store
+type grpcServer struct {
+	rpc.UnimplementedShakeSearchServer
+	s          Store
+	maxResults int // maximum number of hits streamed by Search
+}
+
+func (g *grpcServer) ListTitles(ctx context.Context, req *rpc.ListTitlesRequest) (*rpc.ListTitlesResponse, error) {
+	resp := &rpc.ListTitlesResponse{}
+	for _, title := range g.s.ListTitles() {
+		resp.Titles = append(resp.Titles, &rpc.Title{Title: title.Title, WorkId: title.WorkID, Editions: title.Editions})
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) GetWork(ctx context.Context, req *rpc.GetWorkRequest) (*rpc.Work, error) {
+	work, err := getWork(g.s, req.Id, req.Edition)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &rpc.Work{
+		Id:      work.ID,
+		Edition: work.Edition,
+		Title:   work.Title,
+		Content: work.Content,
+		Year:    int32(work.Year),
+	}, nil
+}
+
+// Search streams every hit of the query, up to the maximum number of hits of an export.
+// Hits are read from the store as they are sent so a slow client holds back the scan
+// instead of buffering the result set. The number of matching lines is sent in the
+// x-total-results trailer, with x-results-truncated if not every one was sent.
+func (g *grpcServer) Search(req *rpc.SearchRequest, stream rpc.ShakeSearch_SearchServer) error {
+	options := newSearchOptions()
+	options.Query = req.Q
+	options.Fuzziness = int(req.Fuzziness)
+	options.WorkID = req.WorkId
+	options.Edition = req.Edition
+	options.Mode = req.Mode
+	options.Regex = req.Regex
+	options.Form = req.Form
+	options.In = req.In
+	options.Meter = req.Meter
+	options.Rhyme = req.Rhyme
+	if len(req.SortBy) > 0 {
+		options.SortBy = req.SortBy
+	}
+	if err := validateSearchOptions(options); err != nil {
+		return grpcError(err)
+	}
+	meta, err := countHits(g.s, options)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	var sendErr error
+	count := 0
+	err = g.s.Scan(options, func(hit store.Hit) error {
+		if count >= g.maxResults {
+			return errExportLimit
+		}
+		count++
+		sendErr = stream.Send(&rpc.Hit{
+			Line:       hit.Line,
+			LineNumber: int32(hit.LineNumber),
+			Score:      hit.Score,
+			Title:      hit.Title,
+			WorkId:     hit.WorkID,
+			Edition:    hit.Edition,
+		})
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil && !errors.Is(err, errExportLimit) {
+		return grpcError(err)
+	}
+	trailer := metadata.Pairs("x-total-results", strconv.Itoa(meta.TotalResults))
+	if meta.Truncated || errors.Is(err, errExportLimit) {
+		trailer.Append("x-results-truncated", "true")
+	}
+	stream.SetTrailer(trailer)
+	return nil
+}
+
+func (g *grpcServer) StreamLines(req *rpc.StreamLinesRequest, stream rpc.ShakeSearch_StreamLinesServer) error {
+	work, err := getWork(g.s, req.WorkId, req.Edition)
+	if err != nil {
+		return grpcError(err)
+	}
+	for _, line := range linesBetween(work.Lines(), int(req.From), int(req.To)) {
+		if err := stream.Send(&rpc.Line{LineNumber: int32(line.LineNumber), Text: line.Text}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newGRPCServer(s Store, config Config) *grpc.Server {
+	server := grpc.NewServer()
+	rpc.RegisterShakeSearchServer(server, &grpcServer{s: s, maxResults: config.MaxExportResults})
+	return server
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sankt-petersbug/shakesearch/rpc"
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func newGRPCTestClient(t *testing.T, s Store) rpc.ShakeSearchClient {
+	return newGRPCTestClientWithConfig(t, s, DefaultConfig())
+}
+
+func newGRPCTestClientWithConfig(t *testing.T, s Store, config Config) rpc.ShakeSearchClient {
+	lis := bufconn.Listen(1024 * 1024)
+	server := newGRPCServer(s, config)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewShakeSearchClient(conn)
+}
+
+func TestGRPC_GetWork(t *testing.T) {
+	testCases := []struct {
+		name            string
+		getWorkByIDFunc func(id string) (store.ShakespeareWork, error)
+		code            codes.Code
+	}{
+		{
+			name: "found",
+			code: codes.OK,
+		},
+		{
+			name: "not found",
+			getWorkByIDFunc: func(id string) (store.ShakespeareWork, error) {
+				return store.ShakespeareWork{}, store.ErrWorkNotFound
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "store error",
+			getWorkByIDFunc: func(id string) (store.ShakespeareWork, error) {
+				return store.ShakespeareWork{}, defaultErr
+			},
+			code: codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newGRPCTestClient(t, &fakeStore{getWorkByIDFunc: tc.getWorkByIDFunc})
+			work, err := client.GetWork(context.Background(), &rpc.GetWorkRequest{Id: "MACBETH"})
+			assert.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				assert.Equal(t, "MACBETH", work.Id)
+			}
+		})
+	}
+}
+
+func TestGRPC_Search(t *testing.T) {
+	var received store.SearchOptions
+	client := newGRPCTestClient(t, &fakeStore{
+		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+			return store.SearchResult{Meta: store.Meta{TotalResults: 3}}, nil
+		},
+		scanFunc: func(options store.SearchOptions, fn func(store.Hit) error) error {
+			received = options
+			for i := 1; i <= 3; i++ {
+				if err := fn(store.Hit{LineNumber: i, WorkID: "MACBETH"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+
+	stream, err := client.Search(context.Background(), &rpc.SearchRequest{Q: "blood", SortBy: []string{"-LineNumber"}})
+	assert.Nil(t, err)
+	var lineNumbers []int32
+	for {
+		hit, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		lineNumbers = append(lineNumbers, hit.LineNumber)
+	}
+	assert.Equal(t, []int32{1, 2, 3}, lineNumbers)
+	assert.Equal(t, []string{"3"}, stream.Trailer().Get("x-total-results"))
+	assert.Empty(t, stream.Trailer().Get("x-results-truncated"))
+	assert.Equal(t, "blood", received.Query)
+	assert.Equal(t, []string{"-LineNumber"}, received.SortBy)
+}
+
+func TestGRPC_Search_Limit(t *testing.T) {
+	scanned := 0
+	client := newGRPCTestClientWithConfig(t, &fakeStore{
+		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+			return store.SearchResult{Meta: store.Meta{TotalResults: 5}}, nil
+		},
+		scanFunc: func(options store.SearchOptions, fn func(store.Hit) error) error {
+			for i := 1; i <= 5; i++ {
+				scanned++
+				if err := fn(store.Hit{LineNumber: i, WorkID: "MACBETH"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, Config{MaxExportResults: 2})
+
+	stream, err := client.Search(context.Background(), &rpc.SearchRequest{Q: "blood"})
+	assert.Nil(t, err)
+	var lineNumbers []int32
+	for {
+		hit, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(t, err) {
+			break
+		}
+		lineNumbers = append(lineNumbers, hit.LineNumber)
+	}
+	assert.Equal(t, []int32{1, 2}, lineNumbers)
+	assert.Equal(t, 3, scanned) // the scan stops at the first hit over the limit
+	assert.Equal(t, []string{"5"}, stream.Trailer().Get("x-total-results"))
+	assert.Equal(t, []string{"true"}, stream.Trailer().Get("x-results-truncated"))
+}
+
+func TestGRPC_Search_InvalidOptions(t *testing.T) {
+	client := newGRPCTestClient(t, &fakeStore{})
+	stream, err := client.Search(context.Background(), &rpc.SearchRequest{Q: "blood", Fuzziness: 5})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_StreamLines(t *testing.T) {
+	client := newGRPCTestClient(t, &fakeStore{
+		getWorkByIDFunc: func(id string) (store.ShakespeareWork, error) {
+			return store.ShakespeareWork{ID: id, Content: "one\n\ntwo\n\nthree"}, nil
+		},
+	})
+
+	stream, err := client.StreamLines(context.Background(), &rpc.StreamLinesRequest{WorkId: "MACBETH", From: 2})
+	assert.Nil(t, err)
+	var texts []string
+	for {
+		line, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		texts = append(texts, line.Text)
+	}
+	assert.Equal(t, []string{"two", "three"}, texts)
+}
@@ -0,0 +1,366 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// openAPIPath is the path the OpenAPI document is served at
+const openAPIPath = "/openapi.json"
+
+// param represents a parameter not described by the query tags of an options struct
+type param struct {
+	name        string
+	kind        string
+	description string
+	required    bool
+}
+
+// operation describes a JSON endpoint. Parameters and schemas are generated from
+// the Go types so the document follows the types used by the handlers.
+type operation struct {
+	method    string
+	path      string // fiber path, e.g. /works/:id
+	summary   string
+	query     interface{} // struct whose query tags are the query parameters
+	params    []param
+	body      interface{}
+	response  interface{}
+	mediaType string // of the response, application/json if empty
+}
+
+// operations are the endpoints described by the OpenAPI document
+var operations = []operation{
+	{
+		method:   http.MethodGet,
+		path:     "/search",
+		summary:  "Search lines of Shakespeare's works",
+		query:    store.SearchOptions{},
+		response: store.SearchResult{},
+	},
+	{
+		method:   http.MethodPost,
+		path:     "/search",
+		summary:  "Search lines with structured queries, filters and facets",
+		body:     store.SearchOptions{},
+		response: store.SearchResult{},
+	},
+	{
+		method:   http.MethodPost,
+		path:     "/search/batch",
+		summary:  "Run up to 100 searches at once",
+		body:     []store.SearchOptions{},
+		response: []batchSearchResult{},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/search/export",
+		summary: "Stream every hit of a search as NDJSON (one hit per line) or CSV",
+		query:   store.SearchOptions{},
+		params: []param{
+			{name: "format", kind: "string", description: "ndjson or csv"},
+		},
+		response:  store.Hit{},
+		mediaType: "application/x-ndjson",
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/titles",
+		summary:  "List the titles of Shakespeare's works",
+		response: []store.Title{},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/works/:id",
+		summary: "Get a work as JSON, or as text, Markdown, TEI XML or EPUB with the Accept header or format",
+		params: []param{
+			{name: "format", kind: "string", description: "json, text, markdown, tei or epub"},
+			{name: "edition", kind: "string", description: "edition of the text, e.g. gutenberg or folger. Defaults to gutenberg, or else the first edition having the work"},
+		},
+		response: store.ShakespeareWork{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/works/:id/diff",
+		summary:  "Align the lines of a work between two editions",
+		query:    diffOptions{},
+		response: store.Diff{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/works/:id/characters",
+		summary:  "List the characters of a play with their aliases, lines and scenes",
+		query:    characterOptions{},
+		response: []store.Character{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/works/:id/characters/:name/stats",
+		summary:  "Count the lines, words and speeches of a character of a play, by name, id or speaker prefix",
+		query:    characterOptions{},
+		response: store.CharacterStats{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/characters/:id",
+		summary:  "Get a character of a play by id, e.g. MACBETH.lady-macbeth",
+		query:    characterOptions{},
+		response: store.Character{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/works/:id/network",
+		summary:  "Get the network of the characters of a play sharing scenes or exchanging speeches as JSON, GraphML or GEXF",
+		query:    networkOptions{},
+		response: store.Network{},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/concordance",
+		summary: "List every occurrence of a term with its context",
+		query:   store.ConcordanceOptions{},
+		params: []param{
+			{name: "format", kind: "string", description: "json, csv or tsv"},
+		},
+		response: store.Concordance{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/quote",
+		summary:  "Find the passages best matching a misremembered quotation with their act.scene.line citation",
+		query:    store.QuoteOptions{},
+		response: store.Quotation{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/stats/terms",
+		summary:  "List the most frequent words",
+		query:    termFrequenciesOptions{},
+		response: []store.TermFrequency{},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/stats/term/:term",
+		summary: "Count the occurrences of a word in each work of an edition",
+		params: []param{
+			{name: "edition", kind: "string", description: "edition of the works. Defaults to gutenberg"},
+		},
+		response: store.TermStats{},
+	},
+	{
+		method:   http.MethodGet,
+		path:     "/stats/collocations",
+		summary:  "List the words appearing most often near a term",
+		query:    store.CollocationOptions{},
+		response: []store.Collocation{},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/stats/trend",
+		summary: "Count the occurrences of a word in each work ordered by composition year",
+		params: []param{
+			{name: "term", kind: "string", required: true},
+			{name: "edition", kind: "string", description: "edition of the works. Defaults to gutenberg"},
+		},
+		response: store.Trend{},
+	},
+}
+
+// schemaBuilder generates JSON schemas from Go types and collects the named ones as components
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+func (s *schemaBuilder) properties(t reflect.Type, props map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			s.properties(field.Type, props)
+			continue
+		}
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		props[name] = s.schema(field.Type)
+	}
+}
+
+func (s *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]interface{})
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if t.Name() == "" {
+			s.properties(t, props)
+			return schema
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := s.components[t.Name()]; !ok {
+			s.components[t.Name()] = schema // registered first as the type can be recursive
+			s.properties(t, props)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// queryParameters returns the parameters described by the query tags of a struct
+func (s *schemaBuilder) queryParameters(t reflect.Type) []interface{} {
+	var params []interface{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" {
+			continue
+		}
+		p := map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": s.schema(field.Type),
+		}
+		if field.Type.Kind() == reflect.Slice {
+			p["explode"] = false // comma-delimited list
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+func (s *schemaBuilder) operation(op operation) map[string]interface{} {
+	var params []interface{}
+	for _, segment := range strings.Split(op.path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, map[string]interface{}{
+				"name":     strings.TrimPrefix(segment, ":"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	if op.query != nil {
+		params = append(params, s.queryParameters(reflect.TypeOf(op.query))...)
+	}
+	for _, p := range op.params {
+		params = append(params, map[string]interface{}{
+			"name":        p.name,
+			"in":          "query",
+			"description": p.description,
+			"required":    p.required,
+			"schema":      map[string]interface{}{"type": p.kind},
+		})
+	}
+
+	errorResponse := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			fiber.MIMEApplicationJSON: map[string]interface{}{"schema": s.schema(reflect.TypeOf(fiber.Error{}))},
+		},
+	}
+	mediaType := op.mediaType
+	if mediaType == "" {
+		mediaType = fiber.MIMEApplicationJSON
+	}
+	spec := map[string]interface{}{
+		"summary": op.summary,
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content": map[string]interface{}{
+					mediaType: map[string]interface{}{"schema": s.schema(reflect.TypeOf(op.response))},
+				},
+			},
+			"default": errorResponse,
+		},
+	}
+	if len(params) > 0 {
+		spec["parameters"] = params
+	}
+	if op.body != nil {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				fiber.MIMEApplicationJSON: map[string]interface{}{"schema": s.schema(reflect.TypeOf(op.body))},
+			},
+		}
+	}
+	return spec
+}
+
+// openAPIPathOf converts a fiber path to an OpenAPI path, e.g. /works/:id to /works/{id}
+func openAPIPathOf(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = fmt.Sprintf("{%s}", strings.TrimPrefix(segment, ":"))
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// newOpenAPISpec returns the OpenAPI 3 document describing the operations served under the prefix
+func newOpenAPISpec(prefix string, operations []operation) map[string]interface{} {
+	builder := &schemaBuilder{components: make(map[string]interface{})}
+	paths := make(map[string]interface{})
+	for _, op := range operations {
+		path := openAPIPathOf(op.path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(op.method)] = builder.operation(op)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "ShakeSearch",
+			"description": "Search William Shakespeare's works",
+			"version":     "1.0.0",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": prefix},
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": builder.components,
+		},
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fill sets every field of v to a non-zero value so that no field is omitted when marshalled
+func fill(v reflect.Value, depth int) {
+	if depth > 3 {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), depth+1)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Float64:
+		v.SetFloat(1)
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0), depth+1)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fill(elem, depth+1)
+		v.SetMapIndex(reflect.ValueOf("x"), elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				fill(v.Field(i), depth+1)
+			}
+		}
+	}
+}
+
+func keys(m map[string]interface{}) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readSpec(t *testing.T) map[string]interface{} {
+	app := newTestApp(&fakeStore{})
+	req, err := http.NewRequest("GET", apiV1Prefix+openAPIPath, nil)
+	if err != nil {
+		panic(err)
+	}
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	byt, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	var spec map[string]interface{}
+	assert.Nil(t, json.Unmarshal(byt, &spec))
+	return spec
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	app := newTestApp(&fakeStore{})
+	var routes, legacyRoutes []string
+	for _, stack := range app.Stack() {
+		for _, route := range stack {
+			if route.Method == http.MethodHead || route.Path == "/" || strings.HasSuffix(route.Path, openAPIPath) || strings.HasSuffix(route.Path, graphqlPath) {
+				continue
+			}
+			if strings.HasPrefix(route.Path, apiV1Prefix) {
+				routes = append(routes, route.Method+" "+strings.TrimPrefix(route.Path, apiV1Prefix))
+			} else {
+				legacyRoutes = append(legacyRoutes, route.Method+" "+route.Path)
+			}
+		}
+	}
+	var documented []string
+	for _, op := range operations {
+		documented = append(documented, op.method+" "+op.path)
+	}
+	sort.Strings(routes)
+	sort.Strings(legacyRoutes)
+	sort.Strings(documented)
+	assert.Equal(t, documented, routes)
+	assert.Equal(t, documented, legacyRoutes)
+}
+
+func TestOpenAPI_SchemasMatchJSON(t *testing.T) {
+	schemas := readSpec(t)["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	for _, op := range operations {
+		for _, v := range []interface{}{op.response, op.body} {
+			if v == nil {
+				continue
+			}
+			typ := reflect.TypeOf(v)
+			if typ.Kind() == reflect.Slice {
+				typ = typ.Elem()
+			}
+			t.Run(op.method+" "+op.path+" "+typ.Name(), func(t *testing.T) {
+				value := reflect.New(typ)
+				fill(value.Elem(), 0)
+				byt, err := json.Marshal(value.Interface())
+				assert.Nil(t, err)
+				var marshalled map[string]interface{}
+				assert.Nil(t, json.Unmarshal(byt, &marshalled))
+
+				schema, ok := schemas[typ.Name()].(map[string]interface{})
+				assert.True(t, ok)
+				properties := schema["properties"].(map[string]interface{})
+				assert.Equal(t, keys(marshalled), keys(properties))
+			})
+		}
+	}
+}
+
+func TestOpenAPI_Paths(t *testing.T) {
+	paths := readSpec(t)["paths"].(map[string]interface{})
+
+	work := paths["/works/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	params := work["parameters"].([]interface{})
+	assert.Equal(t, "id", params[0].(map[string]interface{})["name"])
+	assert.Equal(t, "path", params[0].(map[string]interface{})["in"])
+
+	search := paths["/search"].(map[string]interface{})
+	var names []string
+	for _, p := range search["get"].(map[string]interface{})["parameters"].([]interface{}) {
+		names = append(names, p.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(t, []string{"q", "fuzziness", "workId", "edition", "page[number]", "page[size]", "sortBy", "autocorrect", "mode", "regex", "form", "meter", "rhyme", "in"}, names)
+	assert.Contains(t, search, "post")
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const defaultQuoteLimit = 1
+
+func quoteHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := store.QuoteOptions{
+			Limit: defaultQuoteLimit,
+		}
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		quotation, err := s.Quote(options)
+		if err != nil {
+			return searchError(err)
+		}
+		return c.JSON(quotation)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestRoute_Quote(t *testing.T) {
+	testCases := []struct {
+		name       string
+		url        string
+		quoteFunc  func(store.QuoteOptions) (store.Quotation, error)
+		statusCode int
+	}{
+		{
+			name: "default limit",
+			url:  "/api/v1/quote?text=to%20be%20or%20not%20to%20bee&workId=HAMLET",
+			quoteFunc: func(options store.QuoteOptions) (store.Quotation, error) {
+				assert.Equal(t, store.QuoteOptions{Text: "to be or not to bee", WorkID: "HAMLET", Limit: defaultQuoteLimit}, options)
+				return store.Quotation{Text: options.Text, Matches: []store.QuoteMatch{{Citation: "3.1.56", WorkID: "HAMLET"}}}, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "invalid options",
+			url:  "/api/v1/quote?limit=100",
+			quoteFunc: func(options store.QuoteOptions) (store.Quotation, error) {
+				assert.Equal(t, 100, options.Limit)
+				return store.Quotation{}, store.ErrInvalidSearchOptions
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid limit",
+			url:        "/api/v1/quote?text=sleep&limit=one",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{quoteFunc: tc.quoteFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+			if tc.statusCode == http.StatusOK {
+				var quotation store.Quotation
+				assert.Nil(t, json.NewDecoder(resp.Body).Decode(&quotation))
+				assert.Equal(t, "3.1.56", quotation.Matches[0].Citation)
+			}
+		})
+	}
+}
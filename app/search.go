@@ -0,0 +1,167 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const (
+	maxPageSize         = 1000
+	maxFuzziness        = 2
+	maxBatchSize        = 100
+	batchSearchParallel = 8
+)
+
+// sortFields are the fields search results can be sorted by
+var sortFields = map[string]bool{
+	"Title":      true,
+	"LineNumber": true,
+	"WorkID":     true,
+	"_score":     true,
+}
+
+// batchSearchResult represents the outcome of a single query of a batch
+type batchSearchResult struct {
+	Result *store.SearchResult `json:"result,omitempty"`
+	Error  *fiber.Error        `json:"error,omitempty"`
+}
+
+// newSearchOptions returns the search options used when a parameter is not provided
+func newSearchOptions() store.SearchOptions {
+	return store.SearchOptions{
+		PageSize:   20,
+		PageNumber: 1,
+		SortBy:     []string{"Title", "LineNumber"}, // TODO: case insensitive sort by options
+	}
+}
+
+func validateSearchOptions(options store.SearchOptions) error {
+	if options.PageNumber < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "page[number] must be greater than 0")
+	}
+	if options.PageSize < 1 || options.PageSize > maxPageSize {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("page[size] must be between 1 and %d", maxPageSize))
+	}
+	if options.Fuzziness < 0 || options.Fuzziness > maxFuzziness {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("fuzziness must be between 0 and %d", maxFuzziness))
+	}
+	switch options.Mode {
+	case "", store.ModeWords, store.ModeSubstring, store.ModeRegex:
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid mode: %s", options.Mode))
+	}
+	for _, field := range options.SortBySlice() {
+		if !sortFields[strings.TrimPrefix(field, "-")] {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid sortBy: %s", field))
+		}
+	}
+	return nil
+}
+
+// searchError converts errors caused by invalid search options to bad requests, and
+// errors of searches exceeding the limits of the store to unprocessable ones
+func searchError(err error) error {
+	if errors.Is(err, store.ErrInvalidSearchOptions) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, store.ErrSearchLimit) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	return err
+}
+
+// parseSearchBody returns the search options of a JSON body, using the defaults
+// for the options not provided
+func parseSearchBody(body []byte) (store.SearchOptions, error) {
+	options := newSearchOptions()
+	if err := json.Unmarshal(body, &options); err != nil {
+		return options, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := validateSearchOptions(options); err != nil {
+		return options, err
+	}
+	return options, nil
+}
+
+// batchSearch runs the queries with at most batchSearchParallel of them at the same time
+// and returns their results in the same order
+func batchSearch(s Store, queries []json.RawMessage) []batchSearchResult {
+	results := make([]batchSearchResult, len(queries))
+	sem := make(chan struct{}, batchSearchParallel)
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		options, err := parseSearchBody(query)
+		if err != nil {
+			results[i].Error = toFiberError(err)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, options store.SearchOptions) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			searchResult, err := s.Search(options)
+			if err != nil {
+				results[i].Error = toFiberError(searchError(err))
+				return
+			}
+			results[i].Result = &searchResult
+		}(i, options)
+	}
+	wg.Wait()
+	return results
+}
+
+func searchHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := newSearchOptions()
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if err := validateSearchOptions(options); err != nil {
+			return err
+		}
+		searchResult, err := s.Search(options)
+		if err != nil {
+			return searchError(err)
+		}
+		return c.JSON(searchResult)
+	}
+}
+
+// postSearchHandler searches with options sent as a JSON body, which also accepts
+// structured queries, filters and facets
+func postSearchHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options, err := parseSearchBody(c.Body())
+		if err != nil {
+			return err
+		}
+		searchResult, err := s.Search(options)
+		if err != nil {
+			return searchError(err)
+		}
+		return c.JSON(searchResult)
+	}
+}
+
+func batchSearchHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var queries []json.RawMessage
+		if err := json.Unmarshal(c.Body(), &queries); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "body must be a JSON array of search options")
+		}
+		if len(queries) > maxBatchSize {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("a batch can have at most %d queries", maxBatchSize))
+		}
+		return c.JSON(batchSearch(s, queries))
+	}
+}
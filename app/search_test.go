@@ -0,0 +1,203 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestRoute_Search_Validation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		url        string
+		statusCode int
+	}{
+		{name: "defaults", url: "/search?q=love", statusCode: http.StatusOK},
+		{name: "page number", url: "/search?page[number]=0", statusCode: http.StatusBadRequest},
+		{name: "page size", url: "/search?page[size]=1001", statusCode: http.StatusBadRequest},
+		{name: "fuzziness", url: "/search?fuzziness=3", statusCode: http.StatusBadRequest},
+		{name: "sort by", url: "/search?sortBy=Title,-Text", statusCode: http.StatusBadRequest},
+		{name: "sort by desc", url: "/search?sortBy=-_score,Title", statusCode: http.StatusOK},
+		{name: "mode", url: "/search?q=tis&mode=substring", statusCode: http.StatusOK},
+		{name: "unknown mode", url: "/search?q=tis&mode=fuzzy", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoute_BatchSearch(t *testing.T) {
+	app := newTestApp(&fakeStore{
+		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+			if options.Query == "fail" {
+				return store.SearchResult{}, defaultErr
+			}
+			return store.SearchResult{
+				Data: []store.Hit{{Line: options.Query}},
+				Meta: store.Meta{PageNumber: options.PageNumber, PageSize: options.PageSize},
+			}, nil
+		},
+	})
+	body := `[{"q": "love"}, {"q": "fail"}, {"q": "blood", "page[size]": 0}, {"q": 1}, {"q": "war", "page[size]": 5}]`
+	req, err := http.NewRequest("POST", "/search/batch", strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	byt, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	var results []struct {
+		Result *store.SearchResult `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	assert.Nil(t, json.Unmarshal(byt, &results))
+	assert.Equal(t, 5, len(results))
+
+	assert.Equal(t, "love", results[0].Result.Data[0].Line)
+	assert.Equal(t, 20, results[0].Result.Meta.PageSize)
+	assert.Equal(t, http.StatusInternalServerError, results[1].Error.Code)
+	assert.Equal(t, http.StatusBadRequest, results[2].Error.Code)
+	assert.Equal(t, http.StatusBadRequest, results[3].Error.Code)
+	assert.Equal(t, "war", results[4].Result.Data[0].Line)
+	assert.Equal(t, 5, results[4].Result.Meta.PageSize)
+}
+
+func TestRoute_BatchSearch_InvalidBody(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{name: "not an array", body: `{"q": "love"}`},
+		{name: "too many queries", body: "[" + strings.Repeat(`{"q": "love"},`, maxBatchSize) + `{"q": "love"}]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{})
+			req, err := http.NewRequest("POST", "/search/batch", strings.NewReader(tc.body))
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoute_PostSearch(t *testing.T) {
+	var got store.SearchOptions
+	app := newTestApp(&fakeStore{
+		searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+			got = options
+			return store.SearchResult{}, nil
+		},
+	})
+	body := `{
+		"query": {"bool": {"must": [{"phrase": {"text": "to be"}}]}},
+		"filters": {"workId": ["HAMLET"]},
+		"facets": {"workId": 5},
+		"sortBy": ["-_score"]
+	}`
+	req, err := http.NewRequest("POST", "/search", strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+
+	resp, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, store.SearchOptions{
+		PageNumber: 1,
+		PageSize:   20,
+		SortBy:     []string{"-_score"},
+		Structured: &store.QueryClause{Bool: &store.BoolClause{
+			Must: []store.QueryClause{{Phrase: &store.PhraseClause{Text: "to be"}}},
+		}},
+		Filters: store.Filters{WorkIDs: []string{"HAMLET"}},
+		Facets:  map[string]int{"workId": 5},
+	}, got)
+}
+
+func TestRoute_PostSearch_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		body       string
+		searchFunc func(store.SearchOptions) (store.SearchResult, error)
+		statusCode int
+	}{
+		{
+			name:       "invalid json",
+			body:       `{"q": `,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid page size",
+			body:       `{"page[size]": -1}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid structured query",
+			body: `{"query": {}}`,
+			searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+				return store.SearchResult{}, fmt.Errorf("%w: empty clause", store.ErrInvalidSearchOptions)
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "search limit",
+			body: `{"regex": "."}`,
+			searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+				return store.SearchResult{}, fmt.Errorf("%w: search took too long", store.ErrSearchLimit)
+			},
+			statusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "store error",
+			body: `{}`,
+			searchFunc: func(options store.SearchOptions) (store.SearchResult, error) {
+				return store.SearchResult{}, defaultErr
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{searchFunc: tc.searchFunc})
+			req, err := http.NewRequest("POST", "/search", strings.NewReader(tc.body))
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const (
+	defaultTop    = 50
+	maxTop        = 1000
+	defaultWindow = 5
+	maxWindow     = 20
+)
+
+// termFrequenciesOptions represents the query params of the term frequencies endpoint
+type termFrequenciesOptions struct {
+	WorkID  string `query:"workId"`
+	Edition string `query:"edition"`
+	Top     int    `query:"top"`
+}
+
+func validateTop(top int) error {
+	if top < 1 || top > maxTop {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("top must be between 1 and %d", maxTop))
+	}
+	return nil
+}
+
+func termFrequenciesHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := termFrequenciesOptions{
+			Top: defaultTop,
+		}
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if err := validateTop(options.Top); err != nil {
+			return err
+		}
+
+		frequencies, err := s.TermFrequencies(options.WorkID, options.Edition, options.Top)
+		if err != nil {
+			if errors.Is(err, store.ErrWorkNotFound) {
+				return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("work not found: %s", options.WorkID))
+			}
+			return err
+		}
+		return c.JSON(frequencies)
+	}
+}
+
+func termStatsHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stats, err := s.TermStats(c.Params("term"), c.Query("edition"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(stats)
+	}
+}
+
+func collocationsHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		options := store.CollocationOptions{
+			Window: defaultWindow,
+			Top:    defaultTop,
+		}
+		if err := c.QueryParser(&options); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if options.Term == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "term is required")
+		}
+		if options.Window < 1 || options.Window > maxWindow {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("window must be between 1 and %d", maxWindow))
+		}
+		if err := validateTop(options.Top); err != nil {
+			return err
+		}
+
+		collocations, err := s.Collocations(options)
+		if err != nil {
+			return err
+		}
+		return c.JSON(collocations)
+	}
+}
+
+func trendHandler(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		term := c.Query("term")
+		if term == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "term is required")
+		}
+		trend, err := s.Trend(term, c.Query("edition"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(trend)
+	}
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestRoute_TermFrequencies(t *testing.T) {
+	testCases := []struct {
+		name          string
+		url           string
+		termFreqsFunc func(workID string, edition string, top int) ([]store.TermFrequency, error)
+		statusCode    int
+	}{
+		{
+			name: "default top",
+			url:  "/stats/terms?workId=MACBETH&edition=folger",
+			termFreqsFunc: func(workID string, edition string, top int) ([]store.TermFrequency, error) {
+				assert.Equal(t, "MACBETH", workID)
+				assert.Equal(t, "folger", edition)
+				assert.Equal(t, 50, top)
+				return nil, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "invalid top",
+			url:        "/stats/terms?top=0",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "work not found",
+			url:  "/stats/terms?workId=1",
+			termFreqsFunc: func(workID string, edition string, top int) ([]store.TermFrequency, error) {
+				return nil, store.ErrWorkNotFound
+			},
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{termFreqsFunc: tc.termFreqsFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoute_Collocations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		collocsFunc func(store.CollocationOptions) ([]store.Collocation, error)
+		statusCode  int
+	}{
+		{
+			name: "default options",
+			url:  "/stats/collocations?term=love",
+			collocsFunc: func(options store.CollocationOptions) ([]store.Collocation, error) {
+				assert.Equal(t, store.CollocationOptions{Term: "love", Window: 5, Top: 50}, options)
+				return nil, nil
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "missing term",
+			url:        "/stats/collocations",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "window too large",
+			url:        "/stats/collocations?term=love&window=100",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "store error",
+			url:  "/stats/collocations?term=love",
+			collocsFunc: func(options store.CollocationOptions) ([]store.Collocation, error) {
+				return nil, defaultErr
+			},
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{collocsFunc: tc.collocsFunc})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestRoute_Trend(t *testing.T) {
+	testCases := []struct {
+		name       string
+		url        string
+		edition    string
+		statusCode int
+	}{
+		{name: "success", url: "/stats/trend?term=fortune", statusCode: http.StatusOK},
+		{name: "edition", url: "/stats/trend?term=fortune&edition=folger", edition: "folger", statusCode: http.StatusOK},
+		{name: "missing term", url: "/stats/trend", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newTestApp(&fakeStore{trendFunc: func(term string, edition string) (store.Trend, error) {
+				assert.Equal(t, tc.edition, edition)
+				return store.Trend{Term: term}, nil
+			}})
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode)
+		})
+	}
+}
@@ -3,8 +3,12 @@ module github.com/sankt-petersbug/shakesearch
 go 1.15
 
 require (
-    github.com/blevesearch/bleve v1.0.14
-    github.com/gofiber/fiber/v2 v2.4.1
-    github.com/sirupsen/logrus v1.7.0
-    github.com/stretchr/testify v1.4.0
+	github.com/blevesearch/bleve v1.0.14
+	github.com/gofiber/fiber/v2 v2.4.1
+	github.com/golang/protobuf v1.4.3
+	github.com/graphql-go/graphql v0.8.0
+	github.com/sirupsen/logrus v1.7.0
+	github.com/stretchr/testify v1.5.1
+	google.golang.org/grpc v1.36.0
+	google.golang.org/protobuf v1.25.0
 )
@@ -2,17 +2,25 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 	"unicode"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sankt-petersbug/shakesearch/app"
+	"github.com/sankt-petersbug/shakesearch/prosody"
 	"github.com/sankt-petersbug/shakesearch/store"
+	"github.com/sankt-petersbug/shakesearch/tei"
 )
 
+// defaultPronunciations is the pronunciation dictionary read if PRONUNCIATIONS is not set
+const defaultPronunciations = "cmudict.dict"
+
 func sanitizeTitle(s string) string {
 	return strings.Map(func(c rune) rune {
 		if unicode.IsLetter(c) {
@@ -40,6 +48,89 @@ func readData(fpath string) ([]store.ShakespeareWork, error) {
 	return works, nil
 }
 
+// teiWorkID returns the ID of the work of data.json a TEI work is an edition of: the
+// only work whose ID contains the letters of the TEI title, e.g. THETRAGEDYOFHAMLETPRINCEOFDENMARK
+// for Hamlet. The ID is made from the TEI title if no work or several works contain it.
+func teiWorkID(title string, works []store.ShakespeareWork) string {
+	id := sanitizeTitle(strings.ToUpper(title))
+	match := ""
+	for _, work := range works {
+		if id == "" || !strings.Contains(strings.ToUpper(work.ID), id) || work.ID == match {
+			continue
+		}
+		if match != "" {
+			log.Warnf("TEI work %q matches %s and %s, using %s", title, match, work.ID, id)
+			return id
+		}
+		match = work.ID
+	}
+	if match == "" {
+		log.Warnf("TEI work %q matches no work of data.json, using %s", title, id)
+		return id
+	}
+	return match
+}
+
+// readTEI reads the works of the TEI documents of a directory as an edition. A work
+// has the ID of the work of data.json it is an edition of, so their texts can be compared.
+func readTEI(dir string, edition string, works []store.ShakespeareWork) ([]store.ShakespeareWork, error) {
+	log.Infof("Reading %s edition from %s", edition, dir)
+	teiWorks, err := tei.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(teiWorks); i++ {
+		teiWorks[i].ID = teiWorkID(teiWorks[i].Title, works)
+		teiWorks[i].Edition = edition
+	}
+	log.Infof("Total %d works found in TEI documents", len(teiWorks))
+	return teiWorks, nil
+}
+
+// readPronunciations reads a pronunciation dictionary in the format of the CMU
+// Pronouncing Dictionary, defaultPronunciations if fpath is empty. Without the default
+// dictionary, syllables and stresses are estimated from the spelling of words.
+func readPronunciations(fpath string) (prosody.Dictionary, error) {
+	if fpath == "" {
+		fpath = defaultPronunciations
+		if _, err := os.Stat(fpath); errors.Is(err, os.ErrNotExist) {
+			log.Warnf("%s not found, estimating the meter and rhyme of lines from their spelling. Run make cmudict.dict for better estimates", fpath)
+			return nil, nil
+		}
+	}
+	log.Infof("Reading pronunciations from %s", fpath)
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dict, err := prosody.ReadDictionary(f)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Total %d pronunciations found", len(dict))
+	return dict, nil
+}
+
+// backend is a store the app can both serve and load
+type backend interface {
+	app.Store
+	app.Indexer
+	UsePronunciations(dict prosody.Dictionary)
+}
+
+// newStore returns the store named by kind, bleve by default
+func newStore(kind string) (backend, error) {
+	switch kind {
+	case "", "bleve":
+		return store.NewBleveStore(false)
+	case "memory":
+		return store.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", kind)
+	}
+}
+
 func main() {
 	formatter := &log.TextFormatter{
 		FullTimestamp: true,
@@ -54,17 +145,55 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if teiDir := os.Getenv("TEI_DIR"); teiDir != "" {
+		edition := os.Getenv("TEI_EDITION")
+		if edition == "" {
+			edition = "folger"
+		}
+		teiWorks, err := readTEI(teiDir, edition, works)
+		if err != nil {
+			panic(err)
+		}
+		works = append(works, teiWorks...)
+	}
 
-	app, err := app.NewApp()
+	config := app.DefaultConfig()
+	if maxExportResults := os.Getenv("EXPORT_MAX_RESULTS"); maxExportResults != "" {
+		config.MaxExportResults, err = strconv.Atoi(maxExportResults)
+		if err != nil {
+			panic(err)
+		}
+		if config.MaxExportResults < 1 {
+			panic(fmt.Errorf("EXPORT_MAX_RESULTS must be at least 1, got %d", config.MaxExportResults))
+		}
+	}
+
+	s, err := newStore(os.Getenv("STORE_BACKEND"))
 	if err != nil {
 		panic(err)
 	}
+	pronunciations := os.Getenv("PRONUNCIATIONS")
+	dict, err := readPronunciations(pronunciations)
+	if err != nil {
+		panic(err)
+	}
+	s.UsePronunciations(dict)
+	app := app.NewApp(s, s, config)
 	go func() {
 		if err := app.Load(works); err != nil {
 			panic(err)
 		}
 	}()
 
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		go func() {
+			log.Infof("gRPC server running on %s", grpcPort)
+			if err := app.ListenGRPC(grpcPort); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
 	app.Listen(port)
 	log.Infof("Server running on %s", port)
 }
@@ -0,0 +1,308 @@
+// Package prosody estimates the meter and rhyme of lines of verse, from the
+// pronunciations of a dictionary in the format of the CMU Pronouncing Dictionary
+// or, for the words it lacks, from their spelling.
+package prosody
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Stresses of the syllables of a line
+const (
+	unstressed = '0'
+	stressed   = '1'
+	either     = '?' // monosyllables and secondary stresses can take both
+)
+
+// Dictionary maps uppercase words to their phones, vowels ending with their stress
+// (0, 1 or 2), e.g. DAY to [D EY1]
+type Dictionary map[string][]string
+
+// ReadDictionary reads a dictionary in the format of the CMU Pronouncing Dictionary.
+// Lines starting with ;;; are comments, and only the first pronunciation of a word is kept.
+func ReadDictionary(r io.Reader) (Dictionary, error) {
+	dict := make(Dictionary)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ";;;") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		word := strings.ToUpper(fields[0])
+		if strings.Contains(word, "(") { // alternative pronunciation, e.g. READ(2)
+			continue
+		}
+		if _, ok := dict[word]; !ok {
+			dict[word] = fields[1:]
+		}
+	}
+	return dict, scanner.Err()
+}
+
+// Analyzer estimates the stresses and rhymes of words
+type Analyzer struct {
+	dict Dictionary
+}
+
+// NewAnalyzer returns an analyzer using the pronunciations of dict, which may be nil
+func NewAnalyzer(dict Dictionary) *Analyzer {
+	return &Analyzer{dict: dict}
+}
+
+// words returns the words of a line with their apostrophes
+func words(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’'
+	})
+}
+
+// normalizeWord returns the dictionary entry of a word
+func normalizeWord(word string) string {
+	word = strings.ReplaceAll(word, "’", "'")
+	return strings.ToUpper(strings.Trim(word, "'"))
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouy", r)
+}
+
+// silentEnding reports whether the last vowel of a lowercased word is silent: a final e,
+// or the e of a final -ed or -es, after a consonant, e.g. in tide, loved or loves
+func silentEnding(word string) bool {
+	switch {
+	case strings.HasSuffix(word, "le"):
+		return false
+	case strings.HasSuffix(word, "e"):
+		return true
+	case strings.HasSuffix(word, "ed"):
+		return !strings.HasSuffix(word, "ted") && !strings.HasSuffix(word, "ded")
+	case strings.HasSuffix(word, "es"):
+		for _, sibilant := range []string{"ses", "xes", "zes", "ces", "ges", "ches", "shes"} {
+			if strings.HasSuffix(word, sibilant) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// vowelGroups returns the byte offsets of the groups of vowels of a lowercased word,
+// without a silent ending unless it is the only vowel, e.g. in "the"
+func vowelGroups(word string) [][2]int {
+	var groups [][2]int
+	start := -1
+	for i, r := range word {
+		switch {
+		case isVowel(r) && start < 0:
+			start = i
+		case !isVowel(r) && start >= 0:
+			groups = append(groups, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		groups = append(groups, [2]int{start, len(word)})
+	}
+	if n := len(groups); n > 1 && groups[n-1][1]-groups[n-1][0] == 1 && word[groups[n-1][0]] == 'e' &&
+		groups[n-1][0] >= len(word)-2 && silentEnding(word) {
+		groups = groups[:n-1]
+	}
+	return groups
+}
+
+// spelling returns the lowercased letters of a word
+func spelling(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
+
+// stresses returns the stresses of the syllables of a word. The stress of the syllables
+// of a word missing from the dictionary is unknown.
+func (a *Analyzer) stresses(word string) string {
+	var pattern []rune
+	if phones, ok := a.dict[normalizeWord(word)]; ok {
+		for _, phone := range phones {
+			switch phone[len(phone)-1] {
+			case '0':
+				pattern = append(pattern, unstressed)
+			case '1':
+				pattern = append(pattern, stressed)
+			case '2':
+				pattern = append(pattern, either)
+			}
+		}
+	} else {
+		for range vowelGroups(spelling(word)) {
+			pattern = append(pattern, either)
+		}
+	}
+	if len(pattern) == 1 {
+		return string(either)
+	}
+	return string(pattern)
+}
+
+// Scansion returns the stresses of the syllables of a line: 0 for unstressed, 1 for
+// stressed and ? for syllables which can be either
+func (a *Analyzer) Scansion(line string) string {
+	var pattern strings.Builder
+	for _, word := range words(line) {
+		pattern.WriteString(a.stresses(word))
+	}
+	return pattern.String()
+}
+
+// foot is a metrical foot with its variants commonly found in lines
+type foot struct {
+	name    string
+	pattern string
+	// feminine allows an extra unstressed syllable at the end of the line
+	feminine bool
+	// catalectic allows the last unstressed syllable of the line to be missing
+	catalectic bool
+	// inversion allows the first foot to be reversed
+	inversion bool
+}
+
+// feet are the metrical feet in order of preference
+var feet = []foot{
+	{name: "iambic", pattern: "01", feminine: true, inversion: true},
+	{name: "trochaic", pattern: "10", catalectic: true},
+	{name: "anapestic", pattern: "001"},
+	{name: "dactylic", pattern: "100"},
+}
+
+// lengths are the names of the numbers of feet of a line, from one
+var lengths = []string{"monometer", "dimeter", "trimeter", "tetrameter", "pentameter", "hexameter", "heptameter"}
+
+// Meters returns the names of the meters lines can scan as, e.g. iambic-pentameter
+func Meters() []string {
+	var meters []string
+	for _, f := range feet {
+		for _, length := range lengths {
+			meters = append(meters, f.name+"-"+length)
+		}
+	}
+	return meters
+}
+
+// fits reports whether the stresses of a line fit a pattern
+func fits(scansion string, pattern string) bool {
+	if len(scansion) != len(pattern) {
+		return false
+	}
+	for i := range scansion {
+		if scansion[i] != either && scansion[i] != pattern[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// patterns returns the patterns of stresses of a line of n feet
+func (f foot) patterns(n int) []string {
+	line := strings.Repeat(f.pattern, n)
+	patterns := []string{line}
+	if f.feminine {
+		patterns = append(patterns, line+string(unstressed))
+	}
+	if f.catalectic {
+		patterns = append(patterns, line[:len(line)-1])
+	}
+	if f.inversion {
+		reversed := f.pattern[1:] + f.pattern[:1]
+		for _, p := range patterns {
+			patterns = append(patterns, reversed+p[len(f.pattern):])
+		}
+	}
+	return patterns
+}
+
+// Meter returns the name of the meter a line scans as, e.g. iambic-pentameter, or an
+// empty string if it scans as none. When a line fits several meters, e.g. because its
+// words are all monosyllables, the first of Meters is returned.
+func (a *Analyzer) Meter(line string) string {
+	if meters := a.LineMeters(line); len(meters) > 0 {
+		return meters[0]
+	}
+	return ""
+}
+
+// LineMeters returns the names of every meter a line scans as in the order of Meters.
+// A line of words of unknown stress, e.g. missing from the dictionary, fits every foot
+// of its number of syllables, e.g. both iambic-dimeter and trochaic-dimeter.
+func (a *Analyzer) LineMeters(line string) []string {
+	scansion := a.Scansion(line)
+	if scansion == "" {
+		return nil
+	}
+	var meters []string
+	for _, f := range feet {
+	lengths:
+		for i, length := range lengths {
+			for _, pattern := range f.patterns(i + 1) {
+				if fits(scansion, pattern) {
+					meters = append(meters, f.name+"-"+length)
+					continue lengths
+				}
+			}
+		}
+	}
+	return meters
+}
+
+// RhymeKey returns the sounds from the last stressed vowel to the end of a word, which
+// words rhyming with it share, or an empty string if the word has no vowel. Words missing
+// from the dictionary are keyed by the spelling of their last syllable, e.g. ay for day,
+// and do not rhyme with words of the dictionary.
+func (a *Analyzer) RhymeKey(word string) string {
+	if phones, ok := a.dict[normalizeWord(word)]; ok {
+		last := -1
+		for i, phone := range phones {
+			switch phone[len(phone)-1] {
+			case '1', '2':
+				last = i
+			case '0':
+				if last < 0 {
+					last = i
+				}
+			}
+		}
+		if last < 0 {
+			return ""
+		}
+		key := make([]string, 0, len(phones)-last)
+		for _, phone := range phones[last:] {
+			key = append(key, strings.TrimRight(phone, "012"))
+		}
+		return strings.Join(key, " ")
+	}
+
+	letters := spelling(word)
+	groups := vowelGroups(letters)
+	if len(groups) == 0 {
+		return ""
+	}
+	return letters[groups[len(groups)-1][0]:]
+}
+
+// LineRhymeKey returns the rhyme key of the last word of a line
+func (a *Analyzer) LineRhymeKey(line string) string {
+	w := words(line)
+	if len(w) == 0 {
+		return ""
+	}
+	return a.RhymeKey(w[len(w)-1])
+}
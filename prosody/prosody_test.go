@@ -0,0 +1,150 @@
+package prosody
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDictionary = `;;; a few words of the CMU Pronouncing Dictionary
+AWAY  AH0 W EY1
+DAY  D EY1
+COMPARE  K AH0 M P EH1 R
+THEE  DH IY1
+TO  T UW1
+TO(2)  T IH0
+SUMMER  S AH1 M ER0
+SUMMER'S  S AH1 M ER0 Z
+QUESTION  K W EH1 S CH AH0 N
+TEMPERATE  T EH1 M P ER0 AH0 T
+LOVELY  L AH1 V L IY0
+`
+
+func newTestAnalyzer() *Analyzer {
+	dict, err := ReadDictionary(strings.NewReader(testDictionary))
+	if err != nil {
+		panic(err)
+	}
+	return NewAnalyzer(dict)
+}
+
+func TestReadDictionary(t *testing.T) {
+	dict, err := ReadDictionary(strings.NewReader(testDictionary))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(dict))
+	assert.Equal(t, []string{"T", "UW1"}, dict["TO"])
+}
+
+func TestAnalyzer_Scansion(t *testing.T) {
+	a := newTestAnalyzer()
+	testCases := []struct {
+		line     string
+		expected string
+	}{
+		{line: "", expected: ""},
+		{line: "Shall I compare thee to a summer’s day?", expected: "??01???10?"},
+		{line: "Thou art more lovely and more temperate:", expected: "???10??100"},
+		{line: "The tide", expected: "??"},
+		{line: "He loved, she loves, they kissed", expected: "??????"},
+		{line: "Able kisses", expected: "????"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.line, func(t *testing.T) {
+			assert.Equal(t, tC.expected, a.Scansion(tC.line))
+		})
+	}
+}
+
+func TestAnalyzer_Meter(t *testing.T) {
+	a := newTestAnalyzer()
+	testCases := []struct {
+		line     string
+		expected string
+	}{
+		{line: "Shall I compare thee to a summer’s day?", expected: "iambic-pentameter"},
+		{line: "Thou art more lovely and more temperate:", expected: ""},
+		{line: "So long as men can breathe or eyes can see,", expected: "iambic-pentameter"},
+		{line: "To be, or not to be, that is the question:", expected: "iambic-pentameter"},
+		{line: "Summer, summer, summer, summer", expected: "trochaic-tetrameter"},
+		{line: "Lovely summer, lovely day", expected: "trochaic-tetrameter"},
+		{line: "Away, away", expected: "iambic-dimeter"},
+		{line: "Question and summer", expected: "iambic-dimeter"}, // inverted first foot and feminine ending
+		{line: "Away question", expected: ""},
+		{line: "", expected: ""},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.line, func(t *testing.T) {
+			assert.Equal(t, tC.expected, a.Meter(tC.line))
+		})
+	}
+}
+
+func TestAnalyzer_LineMeters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		analyzer *Analyzer
+		line     string
+		expected []string
+	}{
+		{
+			name:     "stresses",
+			analyzer: newTestAnalyzer(),
+			line:     "Lovely summer, lovely day",
+			expected: []string{"trochaic-tetrameter"},
+		},
+		{
+			name:     "monosyllables",
+			analyzer: newTestAnalyzer(),
+			line:     "So long as men can breathe or eyes can see,",
+			expected: []string{"iambic-pentameter", "trochaic-pentameter"},
+		},
+		{
+			name:     "without dictionary",
+			analyzer: NewAnalyzer(nil),
+			line:     "And the sheen of their spears was like stars",
+			expected: []string{"iambic-tetrameter", "trochaic-pentameter", "anapestic-trimeter", "dactylic-trimeter"},
+		},
+		{
+			name:     "no meter",
+			analyzer: newTestAnalyzer(),
+			line:     "Away question",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, tC.analyzer.LineMeters(tC.line))
+		})
+	}
+}
+
+func TestAnalyzer_RhymeKey(t *testing.T) {
+	a := newTestAnalyzer()
+	testCases := []struct {
+		word     string
+		expected string
+	}{
+		{word: "day", expected: "EY"},
+		{word: "away", expected: "EY"},
+		{word: "Compare", expected: "EH R"},
+		{word: "summer’s", expected: "AH M ER Z"},
+		{word: "play", expected: "ay"},
+		{word: "tide", expected: "ide"},
+		{word: "bright", expected: "ight"},
+		{word: "'tis", expected: "is"},
+		{word: "hmm", expected: ""},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.word, func(t *testing.T) {
+			assert.Equal(t, tC.expected, a.RhymeKey(tC.word))
+		})
+	}
+	assert.Equal(t, "EY", a.LineRhymeKey("Shall I compare thee to a summer’s day?"))
+	assert.Equal(t, "", a.LineRhymeKey("—"))
+}
+
+func TestMeters(t *testing.T) {
+	meters := Meters()
+	assert.Equal(t, 28, len(meters))
+	assert.Contains(t, meters, "iambic-pentameter")
+}
@@ -0,0 +1,214 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// chapter is a file of an EPUB: the front matter, an act or the whole text of a poem
+type chapter struct {
+	File   string
+	Title  string
+	Blocks []store.Block
+	Scenes []store.Scene
+}
+
+// epubPackage is the data of the package document and navigation of an EPUB
+type epubPackage struct {
+	ID       string
+	Title    string
+	Author   string
+	Modified string
+	Chapters []chapter
+}
+
+// epubFile is a file of an EPUB generated from a template
+type epubFile struct {
+	name string
+	tmpl *template.Template
+	data interface{}
+}
+
+func escapeXML(s string) (string, error) {
+	var buf bytes.Buffer
+	if err := xml.EscapeText(&buf, []byte(s)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var epubFuncs = template.FuncMap{
+	"xml":   escapeXML,
+	"lines": texts,
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+var packageTemplate = template.Must(template.New("content.opf").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:shakesearch:{{xml .ID}}</dc:identifier>
+    <dc:title>{{xml .Title}}</dc:title>
+    <dc:creator>{{xml .Author}}</dc:creator>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+{{- range $i, $c := .Chapters}}
+    <item id="chapter-{{$i}}" href="{{$c.File}}" media-type="application/xhtml+xml"/>
+{{- end}}
+  </manifest>
+  <spine>
+{{- range $i, $c := .Chapters}}
+    <itemref idref="chapter-{{$i}}"/>
+{{- end}}
+  </spine>
+</package>
+`))
+
+var navTemplate = template.Must(template.New("nav.xhtml").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="en">
+<head><title>{{xml .Title}}</title></head>
+<body>
+<nav epub:type="toc">
+<h1>{{xml .Title}}</h1>
+<ol>
+{{- range .Chapters}}
+<li><a href="{{.File}}">{{xml .Title}}</a>
+{{- if .Scenes}}
+<ol>
+{{- $file := .File}}
+{{- range $i, $s := .Scenes}}
+<li><a href="{{$file}}#scene-{{$i}}">{{xml $s.Head}}</a></li>
+{{- end}}
+</ol>
+{{- end}}
+</li>
+{{- end}}
+</ol>
+</nav>
+</body>
+</html>
+`))
+
+var chapterTemplate = template.Must(template.New("chapter").Funcs(epubFuncs).Parse(`
+{{- define "blocks"}}
+{{- range .}}
+{{- if eq .Kind "speech"}}
+<div class="speech">
+<p class="speaker">{{xml .Speaker}}</p>
+<p>{{range $i, $l := lines .Lines}}{{if $i}}<br/>{{end}}{{xml $l}}{{end}}</p>
+</div>
+{{- else if eq .Kind "stage"}}
+<p class="stage"><i>{{range $i, $l := lines .Lines}}{{if $i}}<br/>{{end}}{{xml $l}}{{end}}</i></p>
+{{- else}}
+<p>{{range $i, $l := lines .Lines}}{{if $i}}<br/>{{end}}{{xml $l}}{{end}}</p>
+{{- end}}
+{{- end}}
+{{- end -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" lang="en">
+<head><title>{{xml .Title}}</title></head>
+<body>
+<h1>{{xml .Title}}</h1>
+{{- template "blocks" .Blocks}}
+{{- range $i, $s := .Scenes}}
+<section id="scene-{{$i}}">
+<h2>{{xml $s.Head}}</h2>
+{{- template "blocks" $s.Blocks}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// chapters splits a work into the front matter and one chapter per act
+func chapters(work store.ShakespeareWork) []chapter {
+	structure := work.Structure()
+	if len(structure.Acts) == 0 {
+		return []chapter{{File: "text.xhtml", Title: work.Title, Blocks: structure.Blocks}}
+	}
+
+	var c []chapter
+	if len(structure.Blocks) > 0 {
+		c = append(c, chapter{File: "front.xhtml", Title: work.Title, Blocks: structure.Blocks})
+	}
+	for i, act := range structure.Acts {
+		title := act.Head
+		if title == "" {
+			title = work.Title
+		}
+		c = append(c, chapter{
+			File:   fmt.Sprintf("act-%d.xhtml", i),
+			Title:  title,
+			Scenes: act.Scenes,
+		})
+	}
+	return c
+}
+
+// EPUB writes a work as an EPUB 3 book with a chapter for each act. Each scene is
+// listed in the table of contents.
+func EPUB(w io.Writer, work store.ShakespeareWork) error {
+	pkg := epubPackage{
+		ID:       work.ID,
+		Title:    work.Title,
+		Author:   author,
+		Modified: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Chapters: chapters(work),
+	}
+
+	zw := zip.NewWriter(w)
+	// the mimetype must be the first file and is not compressed
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	create := func(name string) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	}
+	container, err := create("META-INF/container.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(container, containerXML); err != nil {
+		return err
+	}
+	files := []epubFile{
+		{name: "OEBPS/content.opf", tmpl: packageTemplate, data: pkg},
+		{name: "OEBPS/nav.xhtml", tmpl: navTemplate, data: pkg},
+	}
+	for _, c := range pkg.Chapters {
+		files = append(files, epubFile{name: "OEBPS/" + c.File, tmpl: chapterTemplate, data: c})
+	}
+	for _, file := range files {
+		fw, err := create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := file.tmpl.Execute(fw, file.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
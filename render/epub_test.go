@@ -0,0 +1,59 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestEPUB(t *testing.T) {
+	work := store.ShakespeareWork{ID: "MACBETH", Title: "MACBETH & CO", Content: testPlay + "\n\nACT II\n\nSCENE I. Inverness.\n\nBANQUO.\nHow goes the night, boy?"}
+	var buf bytes.Buffer
+	assert.Nil(t, EPUB(&buf, work))
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	var names []string
+	files := make(map[string]string)
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		assert.Nil(t, err)
+		byt, err := ioutil.ReadAll(rc)
+		assert.Nil(t, err)
+		rc.Close()
+		files[f.Name] = string(byt)
+
+		// every file other than the mimetype is well-formed XML
+		if f.Name != "mimetype" {
+			dec := xml.NewDecoder(bytes.NewReader(byt))
+			dec.Strict = true
+			for {
+				if _, err := dec.Token(); err != nil {
+					assert.Equal(t, "EOF", err.Error(), f.Name)
+					break
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{
+		"mimetype",
+		"META-INF/container.xml",
+		"OEBPS/content.opf",
+		"OEBPS/nav.xhtml",
+		"OEBPS/front.xhtml",
+		"OEBPS/act-0.xhtml",
+		"OEBPS/act-1.xhtml",
+	}, names)
+	assert.Equal(t, zip.Store, r.File[0].Method)
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	assert.Contains(t, files["OEBPS/content.opf"], "<dc:title>MACBETH &amp; CO</dc:title>")
+	assert.Contains(t, files["OEBPS/nav.xhtml"], `<a href="act-1.xhtml#scene-0">SCENE I. Inverness.</a>`)
+	assert.Contains(t, files["OEBPS/act-0.xhtml"], "<p class=\"speaker\">FIRST WITCH</p>\n<p>When shall we three meet again?<br/>In thunder &amp; lightning?</p>")
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// markdownSyntax matches the start of lines that would be read as Markdown blocks
+var markdownSyntax = regexp.MustCompile(`^(?:#|>|[-+*] |\d+\.)`)
+
+func escapeMarkdown(text string) string {
+	if !markdownSyntax.MatchString(text) {
+		return text
+	}
+	if text[0] >= '0' && text[0] <= '9' {
+		return strings.Replace(text, ".", `\.`, 1) // 1\. is not a list item
+	}
+	return `\` + text
+}
+
+// markdownBlock returns a block as a paragraph with a line break after each line
+func markdownBlock(block store.Block) string {
+	lines := texts(block.Lines)
+	for i, line := range lines {
+		lines[i] = escapeMarkdown(line)
+		if block.Kind == store.BlockStage && !strings.Contains(line, "_") {
+			lines[i] = "_" + line + "_"
+		}
+	}
+	text := strings.Join(lines, "  \n")
+	if block.Kind == store.BlockSpeech {
+		text = "**" + block.Speaker + ".**  \n" + text
+	}
+	return text
+}
+
+// Markdown writes a work with a heading for each act and scene. Speakers are in bold
+// and stage directions in italics.
+func Markdown(w io.Writer, work store.ShakespeareWork) error {
+	bw := bufio.NewWriter(w)
+	paragraph := func(text string) {
+		bw.WriteString(text)
+		bw.WriteString("\n\n")
+	}
+
+	structure := work.Structure()
+	paragraph("# " + work.Title)
+	for _, block := range structure.Blocks {
+		paragraph(markdownBlock(block))
+	}
+	for _, act := range structure.Acts {
+		if act.Head != "" {
+			paragraph("## " + act.Head)
+		}
+		for _, scene := range act.Scenes {
+			if scene.Head != "" {
+				paragraph("### " + scene.Head)
+			}
+			for _, block := range scene.Blocks {
+				paragraph(markdownBlock(block))
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,44 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+func TestMarkdown(t *testing.T) {
+	work := store.ShakespeareWork{Title: "MACBETH", Content: testPlay + "\n\n [_Exeunt._]"}
+	var buf bytes.Buffer
+	assert.Nil(t, Markdown(&buf, work))
+
+	expected := "# MACBETH\n\n" +
+		"MACBETH\n\n" +
+		"## ACT I\n\n" +
+		"### SCENE I. An open Place.\n\n" +
+		"_Thunder and Lightning. Enter three Witches._\n\n" +
+		"**FIRST WITCH.**  \nWhen shall we three meet again?  \nIn thunder & lightning?\n\n" +
+		"[_Exeunt._]\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{text: "plain", expected: "plain"},
+		{text: "# not a heading", expected: `\# not a heading`},
+		{text: "1. not a list", expected: `1\. not a list`},
+		{text: "- not a list", expected: `\- not a list`},
+		{text: "-dash", expected: "-dash"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			assert.Equal(t, tc.expected, escapeMarkdown(tc.text))
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const (
+	// graphMLNamespace is the namespace of GraphML documents
+	graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+	// gexfNamespace is the namespace of GEXF 1.2 documents
+	gexfNamespace = "http://www.gexf.net/1.2draft"
+)
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// GraphML writes the network of the characters of a play as an undirected GraphML graph.
+// Nodes have the name and lines of characters, and edges their scenes, exchanges and weight.
+func GraphML(w io.Writer, network store.Network) error {
+	doc := graphMLDocument{
+		Xmlns: graphMLNamespace,
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", Name: "name", Type: "string"},
+			{ID: "lines", For: "node", Name: "lines", Type: "int"},
+			{ID: "scenes", For: "edge", Name: "scenes", Type: "int"},
+			{ID: "exchanges", For: "edge", Name: "exchanges", Type: "int"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+		},
+		Graph: graphMLGraph{ID: network.WorkID, EdgeDefault: "undirected"},
+	}
+	for _, node := range network.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: node.ID,
+			Data: []graphMLData{
+				{Key: "name", Value: node.Name},
+				{Key: "lines", Value: strconv.Itoa(node.Lines)},
+			},
+		})
+	}
+	for _, edge := range network.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: edge.Source,
+			Target: edge.Target,
+			Data: []graphMLData{
+				{Key: "scenes", Value: strconv.Itoa(edge.Scenes)},
+				{Key: "exchanges", Value: strconv.Itoa(edge.Exchanges)},
+				{Key: "weight", Value: strconv.Itoa(edge.Weight)},
+			},
+		})
+	}
+	return writeXML(w, doc)
+}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator     string `xml:"creator"`
+	Description string `xml:"description"`
+}
+
+type gexfGraph struct {
+	Mode            string           `xml:"mode,attr"`
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Weight int         `xml:"weight,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+// GEXF writes the network of the characters of a play as an undirected GEXF 1.2 graph,
+// labelling nodes with the names of characters and weighting edges by their exchanges
+func GEXF(w io.Writer, network store.Network) error {
+	doc := gexfDocument{
+		Xmlns:   gexfNamespace,
+		Version: "1.2",
+		Meta:    gexfMeta{Creator: "ShakeSearch", Description: network.Title},
+		Graph: gexfGraph{
+			Mode:            "static",
+			DefaultEdgeType: "undirected",
+			Attributes: []gexfAttributes{
+				{Class: "node", Attributes: []gexfAttribute{{ID: "lines", Title: "lines", Type: "integer"}}},
+				{Class: "edge", Attributes: []gexfAttribute{
+					{ID: "scenes", Title: "scenes", Type: "integer"},
+					{ID: "exchanges", Title: "exchanges", Type: "integer"},
+				}},
+			},
+		},
+	}
+	for _, node := range network.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:     node.ID,
+			Label:  node.Name,
+			Values: []gexfValue{{For: "lines", Value: strconv.Itoa(node.Lines)}},
+		})
+	}
+	for i, edge := range network.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     strconv.Itoa(i),
+			Source: edge.Source,
+			Target: edge.Target,
+			Weight: edge.Weight,
+			Values: []gexfValue{
+				{For: "scenes", Value: strconv.Itoa(edge.Scenes)},
+				{For: "exchanges", Value: strconv.Itoa(edge.Exchanges)},
+			},
+		})
+	}
+	return writeXML(w, doc)
+}
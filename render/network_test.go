@@ -0,0 +1,97 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+var testNetwork = store.Network{
+	WorkID: "ROMEO",
+	Title:  "ROMEO & JULIET",
+	Nodes: []store.NetworkNode{
+		{ID: "ROMEO.romeo", Name: "Romeo", Lines: 2},
+		{ID: "ROMEO.juliet", Name: "Juliet", Lines: 3},
+	},
+	Edges: []store.NetworkEdge{
+		{Source: "ROMEO.romeo", Target: "ROMEO.juliet", Scenes: 2, Exchanges: 3, Weight: 3},
+	},
+}
+
+func TestGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, GraphML(&buf, testNetwork))
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="name" for="node" attr.name="name" attr.type="string"></key>
+  <key id="lines" for="node" attr.name="lines" attr.type="int"></key>
+  <key id="scenes" for="edge" attr.name="scenes" attr.type="int"></key>
+  <key id="exchanges" for="edge" attr.name="exchanges" attr.type="int"></key>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"></key>
+  <graph id="ROMEO" edgedefault="undirected">
+    <node id="ROMEO.romeo">
+      <data key="name">Romeo</data>
+      <data key="lines">2</data>
+    </node>
+    <node id="ROMEO.juliet">
+      <data key="name">Juliet</data>
+      <data key="lines">3</data>
+    </node>
+    <edge source="ROMEO.romeo" target="ROMEO.juliet">
+      <data key="scenes">2</data>
+      <data key="exchanges">3</data>
+      <data key="weight">3</data>
+    </edge>
+  </graph>
+</graphml>
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestGEXF(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, GEXF(&buf, testNetwork))
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://www.gexf.net/1.2draft" version="1.2">
+  <meta>
+    <creator>ShakeSearch</creator>
+    <description>ROMEO &amp; JULIET</description>
+  </meta>
+  <graph mode="static" defaultedgetype="undirected">
+    <attributes class="node">
+      <attribute id="lines" title="lines" type="integer"></attribute>
+    </attributes>
+    <attributes class="edge">
+      <attribute id="scenes" title="scenes" type="integer"></attribute>
+      <attribute id="exchanges" title="exchanges" type="integer"></attribute>
+    </attributes>
+    <nodes>
+      <node id="ROMEO.romeo" label="Romeo">
+        <attvalues>
+          <attvalue for="lines" value="2"></attvalue>
+        </attvalues>
+      </node>
+      <node id="ROMEO.juliet" label="Juliet">
+        <attvalues>
+          <attvalue for="lines" value="3"></attvalue>
+        </attvalues>
+      </node>
+    </nodes>
+    <edges>
+      <edge id="0" source="ROMEO.romeo" target="ROMEO.juliet" weight="3">
+        <attvalues>
+          <attvalue for="scenes" value="2"></attvalue>
+          <attvalue for="exchanges" value="3"></attvalue>
+        </attvalues>
+      </edge>
+    </edges>
+  </graph>
+</gexf>
+`
+	assert.Equal(t, expected, buf.String())
+}
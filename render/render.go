@@ -0,0 +1,53 @@
+// Package render renders Shakespeare's works in document formats using the
+// structure (acts, scenes, speeches, ...) detected from their text, and the
+// networks of their characters in graph formats.
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// author is the author of every work
+const author = "William Shakespeare"
+
+// speakerID returns an identifier for a speaker, e.g. first-witch for FIRST WITCH
+func speakerID(speaker string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(strings.ToLower(speaker), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// texts returns the text of lines without surrounding spaces
+func texts(lines []store.Line) []string {
+	t := make([]string, 0, len(lines))
+	for _, line := range lines {
+		t = append(t, strings.TrimSpace(line.Text))
+	}
+	return t
+}
+
+// writeXML writes v as an indented XML document
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,137 @@
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// teiNamespace is the namespace of TEI P5 documents
+const teiNamespace = "http://www.tei-c.org/ns/1.0"
+
+type teiDocument struct {
+	XMLName xml.Name  `xml:"TEI"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Header  teiHeader `xml:"teiHeader"`
+	Text    teiText   `xml:"text"`
+}
+
+type teiHeader struct {
+	Title       string `xml:"fileDesc>titleStmt>title"`
+	Author      string `xml:"fileDesc>titleStmt>author"`
+	Publication string `xml:"fileDesc>publicationStmt>p"`
+	Source      string `xml:"fileDesc>sourceDesc>p"`
+}
+
+type teiText struct {
+	Front *teiDiv `xml:"front>div,omitempty"`
+	Body  teiDiv  `xml:"body"`
+}
+
+// teiDiv is a division (or the body) holding its elements in text order.
+// XMLName is set for divisions nested in other divisions.
+type teiDiv struct {
+	XMLName xml.Name
+	Type    string `xml:"type,attr,omitempty"`
+	N       string `xml:"n,attr,omitempty"`
+	Head    string `xml:"head,omitempty"`
+	Content []interface{}
+}
+
+type teiLine struct {
+	XMLName xml.Name `xml:"l"`
+	N       int      `xml:"n,attr"`
+	Text    string   `xml:",chardata"`
+}
+
+type teiSpeech struct {
+	XMLName xml.Name  `xml:"sp"`
+	Who     string    `xml:"who,attr"`
+	Speaker string    `xml:"speaker"`
+	Lines   []teiLine `xml:"l"`
+}
+
+type teiStage struct {
+	XMLName xml.Name `xml:"stage"`
+	Text    string   `xml:",chardata"`
+}
+
+type teiLineGroup struct {
+	XMLName xml.Name  `xml:"lg"`
+	Lines   []teiLine `xml:"l"`
+}
+
+func teiLines(lines []store.Line) []teiLine {
+	l := make([]teiLine, 0, len(lines))
+	for i, text := range texts(lines) {
+		l = append(l, teiLine{N: lines[i].LineNumber, Text: text})
+	}
+	return l
+}
+
+func teiBlocks(blocks []store.Block) []interface{} {
+	content := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		switch block.Kind {
+		case store.BlockSpeech:
+			content = append(content, teiSpeech{
+				Who:     "#" + speakerID(block.Speaker),
+				Speaker: block.Speaker,
+				Lines:   teiLines(block.Lines),
+			})
+		case store.BlockStage:
+			for _, text := range texts(block.Lines) {
+				content = append(content, teiStage{Text: text})
+			}
+		default:
+			content = append(content, teiLineGroup{Lines: teiLines(block.Lines)})
+		}
+	}
+	return content
+}
+
+func teiScene(scene store.Scene) teiDiv {
+	div := teiDiv{XMLName: xml.Name{Local: "div"}, Type: scene.Type, Head: scene.Head, Content: teiBlocks(scene.Blocks)}
+	if scene.Number > 0 {
+		div.N = strconv.Itoa(scene.Number)
+	}
+	return div
+}
+
+// TEI writes a work as a TEI P5 document. Acts and scenes are divisions, speeches
+// are sp elements and lines keep their line numbers in the n attribute.
+func TEI(w io.Writer, work store.ShakespeareWork) error {
+	doc := teiDocument{
+		Xmlns: teiNamespace,
+		Header: teiHeader{
+			Title:       work.Title,
+			Author:      author,
+			Publication: "Generated by ShakeSearch",
+			Source:      "The Complete Works of William Shakespeare, Project Gutenberg",
+		},
+	}
+	structure := work.Structure()
+	if len(structure.Acts) == 0 {
+		doc.Text.Body.Content = teiBlocks(structure.Blocks)
+	} else if len(structure.Blocks) > 0 {
+		doc.Text.Front = &teiDiv{Content: teiBlocks(structure.Blocks)}
+	}
+	for _, act := range structure.Acts {
+		// parts before the first act are not in an act
+		if act.Number == 0 {
+			for _, scene := range act.Scenes {
+				doc.Text.Body.Content = append(doc.Text.Body.Content, teiScene(scene))
+			}
+			continue
+		}
+		div := teiDiv{XMLName: xml.Name{Local: "div"}, Type: "act", N: strconv.Itoa(act.Number), Head: act.Head}
+		for _, scene := range act.Scenes {
+			div.Content = append(div.Content, teiScene(scene))
+		}
+		doc.Text.Body.Content = append(doc.Text.Body.Content, div)
+	}
+
+	return writeXML(w, doc)
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const testPlay = "MACBETH\n\nACT I\n\nSCENE I. An open Place.\n\n Thunder and Lightning. Enter three Witches.\n\nFIRST WITCH.\nWhen shall we three meet again?\nIn thunder & lightning?"
+
+func TestTEI(t *testing.T) {
+	work := store.ShakespeareWork{ID: "MACBETH", Title: "MACBETH", Content: testPlay}
+	var buf bytes.Buffer
+	assert.Nil(t, TEI(&buf, work))
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<TEI xmlns="http://www.tei-c.org/ns/1.0">
+  <teiHeader>
+    <fileDesc>
+      <titleStmt>
+        <title>MACBETH</title>
+        <author>William Shakespeare</author>
+      </titleStmt>
+      <publicationStmt>
+        <p>Generated by ShakeSearch</p>
+      </publicationStmt>
+      <sourceDesc>
+        <p>The Complete Works of William Shakespeare, Project Gutenberg</p>
+      </sourceDesc>
+    </fileDesc>
+  </teiHeader>
+  <text>
+    <front>
+      <div>
+        <lg>
+          <l n="1">MACBETH</l>
+        </lg>
+      </div>
+    </front>
+    <body>
+      <div type="act" n="1">
+        <head>ACT I</head>
+        <div type="scene" n="1">
+          <head>SCENE I. An open Place.</head>
+          <stage>Thunder and Lightning. Enter three Witches.</stage>
+          <sp who="#first-witch">
+            <speaker>FIRST WITCH</speaker>
+            <l n="10">When shall we three meet again?</l>
+            <l n="11">In thunder &amp; lightning?</l>
+          </sp>
+        </div>
+      </div>
+    </body>
+  </text>
+</TEI>
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestTEI_Poem(t *testing.T) {
+	work := store.ShakespeareWork{Title: "THE SONNETS", Content: "From fairest creatures\nwe desire increase"}
+	var buf bytes.Buffer
+	assert.Nil(t, TEI(&buf, work))
+	assert.NotContains(t, buf.String(), "<front>")
+	assert.Contains(t, buf.String(), `<body>
+      <lg>
+        <l n="1">From fairest creatures</l>
+        <l n="2">we desire increase</l>
+      </lg>
+    </body>`)
+}
+
+func TestSpeakerID(t *testing.T) {
+	assert.Equal(t, "first-witch", speakerID("FIRST WITCH"))
+	assert.Equal(t, "king-henry-v", speakerID("KING HENRY V."))
+}
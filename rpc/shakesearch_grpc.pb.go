@@ -0,0 +1,272 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ShakeSearchClient is the client API for ShakeSearch service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ShakeSearchClient interface {
+	// ListTitles lists the titles of the works
+	ListTitles(ctx context.Context, in *ListTitlesRequest, opts ...grpc.CallOption) (*ListTitlesResponse, error)
+	// GetWork returns a work
+	GetWork(ctx context.Context, in *GetWorkRequest, opts ...grpc.CallOption) (*Work, error)
+	// Search streams every line matching the query in the requested order
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (ShakeSearch_SearchClient, error)
+	// StreamLines streams the non-empty lines of a work
+	StreamLines(ctx context.Context, in *StreamLinesRequest, opts ...grpc.CallOption) (ShakeSearch_StreamLinesClient, error)
+}
+
+type shakeSearchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewShakeSearchClient(cc grpc.ClientConnInterface) ShakeSearchClient {
+	return &shakeSearchClient{cc}
+}
+
+func (c *shakeSearchClient) ListTitles(ctx context.Context, in *ListTitlesRequest, opts ...grpc.CallOption) (*ListTitlesResponse, error) {
+	out := new(ListTitlesResponse)
+	err := c.cc.Invoke(ctx, "/shakesearch.v1.ShakeSearch/ListTitles", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shakeSearchClient) GetWork(ctx context.Context, in *GetWorkRequest, opts ...grpc.CallOption) (*Work, error) {
+	out := new(Work)
+	err := c.cc.Invoke(ctx, "/shakesearch.v1.ShakeSearch/GetWork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shakeSearchClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (ShakeSearch_SearchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ShakeSearch_ServiceDesc.Streams[0], "/shakesearch.v1.ShakeSearch/Search", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shakeSearchSearchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ShakeSearch_SearchClient interface {
+	Recv() (*Hit, error)
+	grpc.ClientStream
+}
+
+type shakeSearchSearchClient struct {
+	grpc.ClientStream
+}
+
+func (x *shakeSearchSearchClient) Recv() (*Hit, error) {
+	m := new(Hit)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shakeSearchClient) StreamLines(ctx context.Context, in *StreamLinesRequest, opts ...grpc.CallOption) (ShakeSearch_StreamLinesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ShakeSearch_ServiceDesc.Streams[1], "/shakesearch.v1.ShakeSearch/StreamLines", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shakeSearchStreamLinesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ShakeSearch_StreamLinesClient interface {
+	Recv() (*Line, error)
+	grpc.ClientStream
+}
+
+type shakeSearchStreamLinesClient struct {
+	grpc.ClientStream
+}
+
+func (x *shakeSearchStreamLinesClient) Recv() (*Line, error) {
+	m := new(Line)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ShakeSearchServer is the server API for ShakeSearch service.
+// All implementations must embed UnimplementedShakeSearchServer
+// for forward compatibility
+type ShakeSearchServer interface {
+	// ListTitles lists the titles of the works
+	ListTitles(context.Context, *ListTitlesRequest) (*ListTitlesResponse, error)
+	// GetWork returns a work
+	GetWork(context.Context, *GetWorkRequest) (*Work, error)
+	// Search streams every line matching the query in the requested order
+	Search(*SearchRequest, ShakeSearch_SearchServer) error
+	// StreamLines streams the non-empty lines of a work
+	StreamLines(*StreamLinesRequest, ShakeSearch_StreamLinesServer) error
+	mustEmbedUnimplementedShakeSearchServer()
+}
+
+// UnimplementedShakeSearchServer must be embedded to have forward compatible implementations.
+type UnimplementedShakeSearchServer struct {
+}
+
+func (UnimplementedShakeSearchServer) ListTitles(context.Context, *ListTitlesRequest) (*ListTitlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTitles not implemented")
+}
+func (UnimplementedShakeSearchServer) GetWork(context.Context, *GetWorkRequest) (*Work, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWork not implemented")
+}
+func (UnimplementedShakeSearchServer) Search(*SearchRequest, ShakeSearch_SearchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedShakeSearchServer) StreamLines(*StreamLinesRequest, ShakeSearch_StreamLinesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLines not implemented")
+}
+func (UnimplementedShakeSearchServer) mustEmbedUnimplementedShakeSearchServer() {}
+
+// UnsafeShakeSearchServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ShakeSearchServer will
+// result in compilation errors.
+type UnsafeShakeSearchServer interface {
+	mustEmbedUnimplementedShakeSearchServer()
+}
+
+func RegisterShakeSearchServer(s grpc.ServiceRegistrar, srv ShakeSearchServer) {
+	s.RegisterService(&ShakeSearch_ServiceDesc, srv)
+}
+
+func _ShakeSearch_ListTitles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTitlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShakeSearchServer).ListTitles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shakesearch.v1.ShakeSearch/ListTitles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShakeSearchServer).ListTitles(ctx, req.(*ListTitlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShakeSearch_GetWork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShakeSearchServer).GetWork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shakesearch.v1.ShakeSearch/GetWork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShakeSearchServer).GetWork(ctx, req.(*GetWorkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShakeSearch_Search_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShakeSearchServer).Search(m, &shakeSearchSearchServer{stream})
+}
+
+type ShakeSearch_SearchServer interface {
+	Send(*Hit) error
+	grpc.ServerStream
+}
+
+type shakeSearchSearchServer struct {
+	grpc.ServerStream
+}
+
+func (x *shakeSearchSearchServer) Send(m *Hit) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ShakeSearch_StreamLines_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamLinesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShakeSearchServer).StreamLines(m, &shakeSearchStreamLinesServer{stream})
+}
+
+type ShakeSearch_StreamLinesServer interface {
+	Send(*Line) error
+	grpc.ServerStream
+}
+
+type shakeSearchStreamLinesServer struct {
+	grpc.ServerStream
+}
+
+func (x *shakeSearchStreamLinesServer) Send(m *Line) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ShakeSearch_ServiceDesc is the grpc.ServiceDesc for ShakeSearch service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ShakeSearch_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shakesearch.v1.ShakeSearch",
+	HandlerType: (*ShakeSearchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTitles",
+			Handler:    _ShakeSearch_ListTitles_Handler,
+		},
+		{
+			MethodName: "GetWork",
+			Handler:    _ShakeSearch_GetWork_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Search",
+			Handler:       _ShakeSearch_Search_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamLines",
+			Handler:       _ShakeSearch_StreamLines_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "shakesearch.proto",
+}
@@ -6,19 +6,26 @@ const Controller = {
     const form = document.getElementById("form");
     const data = Object.fromEntries(new FormData(form));
     const fuzziness = data.fuzzy && data.fuzzy === 'on' ? 1 : 0;
-    const endpoint = `/search?q=${data.query}&fuzziness=${fuzziness}&page[size]=${PageSize}`;
+    const endpoint = `/api/v1/search?q=${data.query}&fuzziness=${fuzziness}&page[size]=${PageSize}`;
     const response = fetch(endpoint).then((response) => {
       response.json().then((results) => {
-        Controller.updateResultView(results);
+        const exportEndpoint = `/api/v1/search/export?q=${encodeURIComponent(data.query)}&fuzziness=${fuzziness}&format=csv`;
+        Controller.updateResultView(results, exportEndpoint);
       });
     });
   },
 
-  updateResultView: (results) => {
+  updateResultView: (results, exportEndpoint) => {
     // total
     const totalDiv = document.getElementById("total");
     const totalResults = results.meta.totalResults;
-    totalDiv.textContent = totalResults ? `${results.meta.totalResults} resutls (showing first ${PageSize})` : 'No results';
+    totalDiv.textContent = totalResults ? `${results.meta.totalResults} resutls (showing first ${PageSize}) ` : 'No results';
+    if (totalResults > PageSize) {
+      const link = document.createElement("a");
+      link.href = exportEndpoint;
+      link.textContent = "download all";
+      totalDiv.appendChild(link);
+    }
 
     // table
     const table = document.getElementById("table-body");
@@ -0,0 +1,300 @@
+package store
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrCharacterNotFound is returned when a character is not in the registry of a work
+var ErrCharacterNotFound = errors.New("character not found")
+
+var (
+	personaePattern = regexp.MustCompile(`(?i)^(?:dramatis person(?:æ|ae)|persons represented)[.:]?$`)
+	settingPattern  = regexp.MustCompile(`(?i)^scene\b`)
+	// personaPattern matches an entry of the dramatis personae: a name in capitals
+	// followed by a description, e.g. "DUNCAN, King of Scotland."
+	personaPattern = regexp.MustCompile(`^([A-Z][A-Z’'&-]+(?: [A-Z][A-Z’'&-]+)*)(?:[,.:]\s*(.*))?$`)
+)
+
+// Appearance is the place of a line in a play
+type Appearance struct {
+	Act        int `json:"act"`
+	Scene      int `json:"scene"`
+	LineNumber int `json:"lineNumber"`
+}
+
+// CharacterScene is a scene a character speaks in
+type CharacterScene struct {
+	Act   int    `json:"act"`
+	Scene int    `json:"scene"`
+	Head  string `json:"head"`
+	Lines int    `json:"lines"` // lines spoken in the scene
+}
+
+// Character represents a character of a play, from its dramatis personae or its speakers
+type Character struct {
+	ID              string           `json:"id"` // work id and name, e.g. MACBETH.lady-macbeth
+	WorkID          string           `json:"workId"`
+	Name            string           `json:"name"` // canonical name, e.g. Lady Macbeth
+	Description     string           `json:"description,omitempty"`
+	Aliases         []string         `json:"aliases"` // speaker prefixes, e.g. LADY M.
+	Lines           int              `json:"lines"`   // lines spoken
+	FirstAppearance *Appearance      `json:"firstAppearance,omitempty"`
+	LastAppearance  *Appearance      `json:"lastAppearance,omitempty"`
+	Scenes          []CharacterScene `json:"scenes"`
+}
+
+// persona is an entry of the dramatis personae of a play
+type persona struct {
+	name        string // in capitals, e.g. LADY MACBETH
+	description string
+}
+
+// readPersonae returns the entries of the dramatis personae in the front matter of a
+// play, from its heading to the setting of the play (e.g. "SCENE: Scotland") or the
+// end of the front matter. Lines not starting with a name in capitals, e.g. "Lords,
+// Gentlemen, Officers", are skipped.
+func readPersonae(blocks []Block) []persona {
+	var personae []persona
+	found := false
+	for _, block := range blocks {
+		for _, line := range block.Lines {
+			text := strings.TrimSpace(line.Text)
+			switch {
+			case personaePattern.MatchString(text):
+				found, personae = true, nil
+			case !found:
+			case settingPattern.MatchString(text):
+				return personae
+			default:
+				if m := personaPattern.FindStringSubmatch(text); m != nil {
+					personae = append(personae, persona{name: m[1], description: strings.TrimSuffix(m[2], ".")})
+				}
+			}
+		}
+	}
+	return personae
+}
+
+// nameWords splits a name or a speaker prefix into its words, e.g. LADY M. into LADY and M
+func nameWords(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '.'
+	})
+}
+
+// abbreviates reports whether the words of a speaker prefix each start the words of a
+// name at the same place, e.g. LADY M for LADY MACBETH
+func abbreviates(speaker []string, name []string) bool {
+	if len(speaker) == 0 || len(speaker) > len(name) {
+		return false
+	}
+	for i, word := range speaker {
+		if !strings.HasPrefix(name[i], word) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPersona returns the index of the persona a speaker prefix stands for: the entry
+// with the same name, or else the only entry abbreviated by it. It returns -1 if no
+// entry or several entries are abbreviated by it.
+func matchPersona(speaker string, personae []persona) int {
+	words := nameWords(strings.ToUpper(speaker))
+	for i, p := range personae {
+		if strings.Join(words, " ") == strings.Join(nameWords(p.name), " ") {
+			return i
+		}
+	}
+	match := -1
+	for i, p := range personae {
+		if abbreviates(words, nameWords(p.name)) {
+			if match >= 0 {
+				return -1
+			}
+			match = i
+		}
+	}
+	return match
+}
+
+// canonicalName returns a name in capitals with the first letter of its words
+// capitalized, e.g. Lady Macbeth for LADY MACBETH
+func canonicalName(name string) string {
+	runes := []rune(strings.ToLower(name))
+	for i, r := range runes {
+		if i == 0 || runes[i-1] == ' ' || runes[i-1] == '-' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// characterID returns the id of a character of a work
+func characterID(workID string, name string) string {
+	slug := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return workID + "." + strings.Join(slug, "-")
+}
+
+// speech is the lines a character speaks in a row in a scene. Stage directions do not
+// end a speech, but another speaker does.
+type speech struct {
+	character int // index of the character speaking
+	act       Act
+	scene     Scene
+	sceneID   int // index of the scene in the play
+	lines     []Line
+}
+
+// cast holds the characters of a work and their speeches
+type cast struct {
+	work       ShakespeareWork
+	characters []Character
+	stats      []CharacterStats // stats of the characters in the same order
+	speeches   []speech
+}
+
+// characterIndex returns the index of the character with the id, or -1 if there is none
+func (c *cast) characterIndex(id string) int {
+	for i, character := range c.characters {
+		if character.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// readCast returns the characters of a work: the entries of its dramatis personae
+// in order, then the speakers not found in it in order of appearance. Speakers are
+// matched to the entry they abbreviate, e.g. HAM to HAMLET, and speakers with the same
+// id, e.g. Lord and LORD, are the same character. The words of speeches
+// are split by tokenize.
+func readCast(work ShakespeareWork, tokenize func(text string) []token) *cast {
+	structure := work.Structure()
+	personae := readPersonae(structure.Blocks)
+	c := &cast{work: work, characters: make([]Character, 0, len(personae))}
+	for _, p := range personae {
+		name := canonicalName(p.name)
+		c.characters = append(c.characters, Character{
+			ID:          characterID(work.ID, name),
+			WorkID:      work.ID,
+			Name:        name,
+			Description: p.description,
+			Aliases:     []string{},
+			Scenes:      []CharacterScene{},
+		})
+	}
+
+	bySpeaker := make(map[string]int) // index of the character of each speaker prefix
+	character := func(speaker string) int {
+		i, ok := bySpeaker[speaker]
+		if ok {
+			return i
+		}
+		i = matchPersona(speaker, personae)
+		if i < 0 {
+			i = c.characterIndex(characterID(work.ID, canonicalName(speaker)))
+		}
+		if i < 0 {
+			name := canonicalName(speaker)
+			i = len(c.characters)
+			c.characters = append(c.characters, Character{
+				ID:     characterID(work.ID, name),
+				WorkID: work.ID,
+				Name:   name,
+				Scenes: []CharacterScene{},
+			})
+		}
+		bySpeaker[speaker] = i
+		c.characters[i].Aliases = append(c.characters[i].Aliases, speaker+".")
+		return i
+	}
+
+	sceneID := 0
+	for _, act := range structure.Acts {
+		for _, scene := range act.Scenes {
+			sceneID++
+			for _, block := range scene.Blocks {
+				if block.Kind != BlockSpeech || block.Speaker == "" || len(block.Lines) == 0 {
+					continue
+				}
+				i := character(block.Speaker)
+				if n := len(c.speeches); n > 0 && c.speeches[n-1].sceneID == sceneID && c.speeches[n-1].character == i {
+					c.speeches[n-1].lines = append(c.speeches[n-1].lines, block.Lines...)
+					continue
+				}
+				lines := append([]Line{}, block.Lines...)
+				c.speeches = append(c.speeches, speech{character: i, act: act, scene: scene, sceneID: sceneID, lines: lines})
+			}
+		}
+	}
+
+	for _, s := range c.speeches {
+		character := &c.characters[s.character]
+		if n := len(character.Scenes); n == 0 || character.Scenes[n-1].Act != s.act.Number || character.Scenes[n-1].Head != s.scene.Head {
+			character.Scenes = append(character.Scenes, CharacterScene{Act: s.act.Number, Scene: s.scene.Number, Head: s.scene.Head})
+		}
+		character.Scenes[len(character.Scenes)-1].Lines += len(s.lines)
+		character.Lines += len(s.lines)
+		if character.FirstAppearance == nil {
+			character.FirstAppearance = &Appearance{Act: s.act.Number, Scene: s.scene.Number, LineNumber: s.lines[0].LineNumber}
+		}
+		character.LastAppearance = &Appearance{Act: s.act.Number, Scene: s.scene.Number, LineNumber: s.lines[len(s.lines)-1].LineNumber}
+	}
+	c.stats = c.characterStats(tokenize)
+	return c
+}
+
+// cast returns the cast of a work in an edition, or in the edition of GetWorkByID if
+// edition is empty
+func (c *corpus) cast(workID string, edition string) (*cast, error) {
+	var work ShakespeareWork
+	var err error
+	if edition == "" {
+		work, err = c.GetWorkByID(workID)
+	} else {
+		work, err = c.GetWorkByEdition(workID, edition)
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, ok := c.casts.Load(workKey(work.ID, work.Edition))
+	if !ok {
+		return nil, ErrWorkNotFound
+	}
+	return found.(*cast), nil
+}
+
+// Characters returns the characters of a work in an edition, or in the edition of
+// GetWorkByID if edition is empty
+func (c *corpus) Characters(workID string, edition string) ([]Character, error) {
+	found, err := c.cast(workID, edition)
+	if err != nil {
+		return nil, err
+	}
+	return found.characters, nil
+}
+
+// GetCharacter returns the character with the id in an edition, or in the edition of
+// GetWorkByID if edition is empty
+func (c *corpus) GetCharacter(id string, edition string) (Character, error) {
+	workID := id
+	if i := strings.Index(id, "."); i >= 0 {
+		workID = id[:i]
+	}
+	characters, err := c.Characters(workID, edition)
+	if err != nil {
+		return Character{}, err
+	}
+	for _, character := range characters {
+		if character.ID == id {
+			return character, nil
+		}
+	}
+	return Character{}, ErrCharacterNotFound
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCharactersPlay = `THE TRAGEDY OF HAMLET, PRINCE OF DENMARK
+
+Dramatis Personæ
+
+HAMLET, Prince of Denmark.
+HORATIO, Friend to Hamlet.
+Lords, Ladies, Officers.
+GHOST.
+
+SCENE: Elsinore.
+
+ACT I
+
+SCENE I. Elsinore. A platform before the Castle.
+
+ Enter Horatio.
+
+HOR.
+Who’s there?
+
+HAMLET.
+Nay, answer me.
+
+FRANCISCO.
+Long live the King!
+
+SCENE II. Elsinore. A room of state in the Castle.
+
+HAM.
+A little more than kin, and less than kind.
+Not so, my lord.
+
+ [_Aside._]
+
+HAM.
+I am too much in the sun.`
+
+func TestReadPersonae(t *testing.T) {
+	structure := ShakespeareWork{Content: testCharactersPlay}.Structure()
+	assert.Equal(t, []persona{
+		{name: "HAMLET", description: "Prince of Denmark"},
+		{name: "HORATIO", description: "Friend to Hamlet"},
+		{name: "GHOST"},
+	}, readPersonae(structure.Blocks))
+	assert.Empty(t, readPersonae(nil))
+}
+
+func TestMatchPersona(t *testing.T) {
+	personae := []persona{{name: "LADY MACBETH"}, {name: "MACBETH"}, {name: "MALCOLM"}, {name: "FIRST WITCH"}}
+	testCases := []struct {
+		speaker  string
+		expected int
+	}{
+		{speaker: "MACBETH", expected: 1},
+		{speaker: "MACB", expected: 1},
+		{speaker: "LADY M.", expected: 0},
+		{speaker: "Lady Macbeth", expected: 0},
+		{speaker: "MA", expected: -1}, // both Macbeth and Malcolm
+		{speaker: "1 WITCH", expected: -1},
+		{speaker: "PORTER", expected: -1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.speaker, func(t *testing.T) {
+			assert.Equal(t, tC.expected, matchPersona(tC.speaker, personae))
+		})
+	}
+
+	// an exact match wins over the entries the name abbreviates
+	lords := []persona{{name: "LORD CAPULET"}, {name: "LORD MONTAGUE"}, {name: "LORD"}}
+	assert.Equal(t, 2, matchPersona("LORD", lords))
+	assert.Equal(t, -1, matchPersona("LO", lords))
+}
+
+func TestCorpus_Characters_UniqueIDs(t *testing.T) {
+	play := "Dramatis Personæ\n\nLORD CAPULET.\nLORD MONTAGUE.\nLORD.\n\nACT I\n\nSCENE I. Verona.\n\nLORD.\nHold.\n\nLORD CAPULET.\nWhat noise is this?\n\nCAPULET.\nGive me my sword."
+	s := newTestMemoryStore([]ShakespeareWork{{ID: "ROMEO", Title: "Romeo", Content: play}})
+
+	characters, err := s.Characters("ROMEO", "")
+	assert.Nil(t, err)
+	ids := make(map[string]bool)
+	for _, c := range characters {
+		assert.False(t, ids[c.ID], c.ID)
+		ids[c.ID] = true
+	}
+	lord, err := s.GetCharacter("ROMEO.lord", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"LORD."}, lord.Aliases)
+	assert.Equal(t, 1, lord.Lines)
+}
+
+func TestCanonicalName(t *testing.T) {
+	assert.Equal(t, "Lady Macbeth", canonicalName("LADY MACBETH"))
+	assert.Equal(t, "Mistress Quickly", canonicalName("MISTRESS QUICKLY"))
+	assert.Equal(t, "Second Lord", canonicalName("Second Lord"))
+	assert.Equal(t, "MACBETH.lady-macbeth", characterID("MACBETH", "Lady Macbeth"))
+}
+
+func TestCorpus_Characters(t *testing.T) {
+	s := newTestMemoryStore([]ShakespeareWork{{ID: "HAMLET", Title: "Hamlet", Content: testCharactersPlay}})
+
+	characters, err := s.Characters("HAMLET", "")
+	assert.Nil(t, err)
+	var names []string
+	for _, c := range characters {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Hamlet", "Horatio", "Ghost", "Francisco"}, names)
+
+	hamlet := characters[0]
+	assert.Equal(t, "HAMLET.hamlet", hamlet.ID)
+	assert.Equal(t, "Prince of Denmark", hamlet.Description)
+	assert.Equal(t, []string{"HAMLET.", "HAM."}, hamlet.Aliases)
+	assert.Equal(t, 4, hamlet.Lines)
+	assert.Equal(t, &Appearance{Act: 1, Scene: 1, LineNumber: 22}, hamlet.FirstAppearance)
+	assert.Equal(t, &Appearance{Act: 1, Scene: 2, LineNumber: 36}, hamlet.LastAppearance)
+	assert.Equal(t, []CharacterScene{
+		{Act: 1, Scene: 1, Head: "SCENE I. Elsinore. A platform before the Castle.", Lines: 1},
+		{Act: 1, Scene: 2, Head: "SCENE II. Elsinore. A room of state in the Castle.", Lines: 3},
+	}, hamlet.Scenes)
+
+	assert.Equal(t, []string{"HOR."}, characters[1].Aliases)
+	assert.Nil(t, characters[2].FirstAppearance)
+	assert.Empty(t, characters[2].Scenes)
+
+	francisco, err := s.GetCharacter("HAMLET.francisco", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"FRANCISCO."}, francisco.Aliases)
+	assert.Equal(t, 1, francisco.Lines)
+
+	_, err = s.GetCharacter("HAMLET.ophelia", "")
+	assert.True(t, errors.Is(err, ErrCharacterNotFound))
+	_, err = s.GetCharacter("MACBETH.macbeth", "")
+	assert.True(t, errors.Is(err, ErrWorkNotFound))
+	_, err = s.Characters("HAMLET", "folger")
+	assert.True(t, errors.Is(err, ErrWorkNotFound))
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"sort"
+)
+
+// compositionYears are the approximate years each work was written, keyed by work id.
+// Dates follow the chronology commonly given by modern editions; many are disputed
+// and a work with a year in data.json takes precedence.
+var compositionYears = map[string]int{
+	"THETWOGENTLEMENOFVERONA":           1590,
+	"THETAMINGOFTHESHREW":               1591,
+	"THESECONDPARTOFKINGHENRYTHESIXTH":  1591,
+	"THETHIRDPARTOFKINGHENRYTHESIXTH":   1591,
+	"THEFIRSTPARTOFHENRYTHESIXTH":       1592,
+	"THETRAGEDYOFTITUSANDRONICUS":       1592,
+	"KINGRICHARDTHETHIRD":               1593,
+	"VENUSANDADONIS":                    1593,
+	"THERAPEOFLUCRECE":                  1594,
+	"THECOMEDYOFERRORS":                 1594,
+	"LOVESLABOURSLOST":                  1595,
+	"KINGRICHARDTHESECOND":              1595,
+	"THETRAGEDYOFROMEOANDJULIET":        1595,
+	"AMIDSUMMERNIGHTSDREAM":             1595,
+	"KINGJOHN":                          1596,
+	"THEMERCHANTOFVENICE":               1596,
+	"THEFIRSTPARTOFKINGHENRYTHEFOURTH":  1596,
+	"THEMERRYWIVESOFWINDSOR":            1597,
+	"THESECONDPARTOFKINGHENRYTHEFOURTH": 1597,
+	"MUCHADOABOUTNOTHING":               1598,
+	"THELIFEOFKINGHENRYV":               1599,
+	"THETRAGEDYOFJULIUSCAESAR":          1599,
+	"ASYOULIKEIT":                       1599,
+	"THEPASSIONATEPILGRIM":              1599,
+	"THETRAGEDYOFHAMLETPRINCEOFDENMARK": 1600,
+	"TWELFTHNIGHTORWHATYOUWILL":         1601,
+	"THEPHOENIXANDTHETURTLE":            1601,
+	"THEHISTORYOFTROILUSANDCRESSIDA":    1602,
+	"MEASUREFORMEASURE":                 1603,
+	"OTHELLOTHEMOOROFVENICE":            1604,
+	"ALLSWELLTHATENDSWELL":              1605,
+	"THELIFEOFTIMONOFATHENS":            1605,
+	"THETRAGEDYOFKINGLEAR":              1605,
+	"MACBETH":                           1606,
+	"ANTONYANDCLEOPATRA":                1606,
+	"PERICLESPRINCEOFTYRE":              1607,
+	"THETRAGEDYOFCORIOLANUS":            1608,
+	"THEWINTERSTALE":                    1609,
+	"THESONNETS":                        1609,
+	"ALOVERSCOMPLAINT":                  1609,
+	"CYMBELINE":                         1610,
+	"THETEMPEST":                        1611,
+	"KINGHENRYTHEEIGHTH":                1613,
+	"THETWONOBLEKINSMEN":                1613,
+}
+
+// CompositionYear returns the year a work was written or 0 if unknown
+func CompositionYear(work ShakespeareWork) int {
+	if work.Year != 0 {
+		return work.Year
+	}
+	return compositionYears[work.ID]
+}
+
+// TrendPoint represents the frequency of a term in a work written in a given year
+type TrendPoint struct {
+	WorkTermFrequency
+	Year int `json:"year"`
+}
+
+// Trend represents the frequency of a term across the works of an edition ordered by composition year
+type Trend struct {
+	Edition string       `json:"edition"`
+	Term    string       `json:"term"`
+	Works   []TrendPoint `json:"works"`
+}
+
+// Trend returns the frequency of a term in each work of an edition, DefaultEdition if
+// empty, ordered chronologically. Works with an unknown composition year are left out.
+func (c *corpus) Trend(term string, edition string) (Trend, error) {
+	termStats, err := c.TermStats(term, edition)
+	if err != nil {
+		return Trend{}, err
+	}
+	trend := Trend{
+		Edition: termStats.Edition,
+		Term:    termStats.Term,
+		Works:   make([]TrendPoint, 0, len(termStats.Works)),
+	}
+	for _, frequency := range termStats.Works {
+		work, err := c.GetWorkByEdition(frequency.WorkID, termStats.Edition)
+		if err != nil {
+			return trend, err
+		}
+		year := CompositionYear(work)
+		if year == 0 {
+			continue
+		}
+		trend.Works = append(trend.Works, TrendPoint{WorkTermFrequency: frequency, Year: year})
+	}
+	sort.SliceStable(trend.Works, func(i, j int) bool {
+		return trend.Works[i].Year < trend.Works[j].Year
+	})
+	return trend, nil
+}
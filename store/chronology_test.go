@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositionYear(t *testing.T) {
+	assert.Equal(t, 1606, CompositionYear(ShakespeareWork{ID: "MACBETH"}))
+	assert.Equal(t, 1623, CompositionYear(ShakespeareWork{ID: "MACBETH", Year: 1623}))
+	assert.Equal(t, 0, CompositionYear(ShakespeareWork{ID: "UNKNOWN"}))
+}
+
+func TestBleveStore_Trend(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "THETEMPEST", Title: "THE TEMPEST", Content: "fortune fortune"},
+		{ID: "UNKNOWN", Title: "A NEW WORK", Content: "fortune"},
+		{ID: "KINGJOHN", Title: "KING JOHN", Content: "fortune and ill fate"},
+		{ID: "EARLY", Title: "EARLY", Content: "no luck", Year: 1580},
+	}
+	searcher := newTestStore(data)
+
+	trend, err := searcher.Trend("Fortune", "")
+	assert.Nil(t, err)
+	assert.Equal(t, Trend{
+		Edition: DefaultEdition,
+		Term:    "fortune",
+		Works: []TrendPoint{
+			{
+				WorkTermFrequency: WorkTermFrequency{Count: 0, Frequency: 0, Title: "EARLY", TotalWords: 2, WorkID: "EARLY"},
+				Year:              1580,
+			},
+			{
+				WorkTermFrequency: WorkTermFrequency{Count: 1, Frequency: 2500, Title: "KING JOHN", TotalWords: 4, WorkID: "KINGJOHN"},
+				Year:              1596,
+			},
+			{
+				WorkTermFrequency: WorkTermFrequency{Count: 2, Frequency: 10000, Title: "THE TEMPEST", TotalWords: 2, WorkID: "THETEMPEST"},
+				Year:              1611,
+			},
+		},
+	}, trend)
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConcordanceOptions represents the options to build a concordance
+type ConcordanceOptions struct {
+	Term   string `query:"term"`
+	Width  int    `query:"width"`
+	WorkID string `query:"workId"`
+	Sort   string `query:"sort"` // left, right or empty to keep the text order
+}
+
+// ConcordanceLine represents an occurrence of a keyword with its left and right context
+type ConcordanceLine struct {
+	Keyword    string `json:"keyword"`
+	Left       string `json:"left"`
+	LineNumber int    `json:"lineNumber"`
+	Right      string `json:"right"`
+	Title      string `json:"title"`
+	WorkID     string `json:"workId"`
+}
+
+// ConcordanceMeta represents non-standard meta-information in Concordance
+type ConcordanceMeta struct {
+	Term         string `json:"term"`
+	TotalResults int    `json:"totalResults"`
+	Width        int    `json:"width"`
+}
+
+// Concordance represents every occurrence of a term aligned on the keyword
+type Concordance struct {
+	Data []ConcordanceLine `json:"data"`
+	Meta ConcordanceMeta   `json:"meta"`
+}
+
+// lastRunes returns at most n runes from the end of s
+func lastRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[len(r)-n:]
+	}
+	return string(r)
+}
+
+// firstRunes returns at most n runes from the start of s
+func firstRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[:n]
+	}
+	return string(r)
+}
+
+// reverseWords returns the words of s in reverse order so the word next to
+// the keyword is compared first when sorting by the left context
+func reverseWords(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+		words[i], words[j] = words[j], words[i]
+	}
+	return strings.Join(words, " ")
+}
+
+// neighbour returns the indexed line next to docID within the same work and edition
+func (c *corpus) neighbour(docID string, offset int, of Document) (Document, string, bool) {
+	n, err := strconv.Atoi(docID)
+	if err != nil {
+		return Document{}, "", false
+	}
+	id := strconv.Itoa(n + offset)
+	found, ok := c.lines.Load(id)
+	if !ok {
+		return Document{}, "", false
+	}
+	doc, ok := found.(Document)
+	if !ok || doc.WorkID != of.WorkID || doc.Edition != of.Edition {
+		return Document{}, "", false
+	}
+	return doc, id, true
+}
+
+// leftContext returns the text preceding the keyword, continuing on previous lines if needed
+func (c *corpus) leftContext(docID string, doc Document, start int, width int) string {
+	text := doc.Text[:start]
+	for id := docID; len([]rune(text)) < width; {
+		prev, prevID, ok := c.neighbour(id, -1, doc)
+		if !ok {
+			break
+		}
+		text = prev.line() + " " + text
+		id = prevID
+	}
+	return lastRunes(text, width)
+}
+
+// rightContext returns the text following the keyword, continuing on next lines if needed
+func (c *corpus) rightContext(docID string, doc Document, end int, width int) string {
+	text := doc.Text[end:]
+	for id := docID; len([]rune(text)) < width; {
+		next, nextID, ok := c.neighbour(id, 1, doc)
+		if !ok {
+			break
+		}
+		text = text + " " + next.line()
+		id = nextID
+	}
+	return firstRunes(text, width)
+}
+
+// Concordance finds every occurrence of a term and returns it with a fixed width of context
+func (c *corpus) Concordance(options ConcordanceOptions) (Concordance, error) {
+	concordance := Concordance{
+		Data: make([]ConcordanceLine, 0),
+		Meta: ConcordanceMeta{
+			Term:  options.Term,
+			Width: options.Width,
+		},
+	}
+
+	err := c.occurrences(options.Term, options.WorkID, func(docID string, doc Document, occurrences []token) error {
+		lineNumber, err := parseZeroPaddedNumber(doc.LineNumber)
+		if err != nil {
+			return err
+		}
+		for _, occurrence := range occurrences {
+			start, end := occurrence.start, occurrence.end
+			concordance.Data = append(concordance.Data, ConcordanceLine{
+				Keyword:    doc.Text[start:end],
+				Left:       c.leftContext(docID, doc, start, options.Width),
+				LineNumber: lineNumber,
+				Right:      c.rightContext(docID, doc, end, options.Width),
+				Title:      doc.Title,
+				WorkID:     doc.WorkID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return concordance, err
+	}
+
+	switch options.Sort {
+	case "left":
+		sort.SliceStable(concordance.Data, func(i, j int) bool {
+			return reverseWords(concordance.Data[i].Left) < reverseWords(concordance.Data[j].Left)
+		})
+	case "right":
+		sort.SliceStable(concordance.Data, func(i, j int) bool {
+			return strings.ToLower(concordance.Data[i].Right) < strings.ToLower(concordance.Data[j].Right)
+		})
+	}
+	concordance.Meta.TotalResults = len(concordance.Data)
+	return concordance, nil
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBleveStore_Concordance(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "will all great Neptune’s ocean\nwash this blood clean from my hand"},
+		{ID: "2", Title: "TitleB", Content: "blood will have blood"},
+	}
+	searcher := newTestStore(data)
+
+	testCases := []struct {
+		name     string
+		options  ConcordanceOptions
+		expected []ConcordanceLine
+	}{
+		{
+			name:    "text order with context from neighbour lines",
+			options: ConcordanceOptions{Term: "blood", Width: 10},
+			expected: []ConcordanceLine{
+				{Keyword: "blood", Left: "wash this ", LineNumber: 2, Right: " clean fro", Title: "TitleA", WorkID: "1"},
+				{Keyword: "blood", Left: "", LineNumber: 1, Right: " will have", Title: "TitleB", WorkID: "2"},
+				{Keyword: "blood", Left: "will have ", LineNumber: 1, Right: "", Title: "TitleB", WorkID: "2"},
+			},
+		},
+		{
+			name:    "left context continues on previous line",
+			options: ConcordanceOptions{Term: "wash", Width: 8},
+			expected: []ConcordanceLine{
+				{Keyword: "wash", Left: "s ocean ", LineNumber: 2, Right: " this bl", Title: "TitleA", WorkID: "1"},
+			},
+		},
+		{
+			name:    "sort by left context",
+			options: ConcordanceOptions{Term: "blood", Width: 10, Sort: "left"},
+			expected: []ConcordanceLine{
+				{Keyword: "blood", Left: "", LineNumber: 1, Right: " will have", Title: "TitleB", WorkID: "2"},
+				{Keyword: "blood", Left: "will have ", LineNumber: 1, Right: "", Title: "TitleB", WorkID: "2"},
+				{Keyword: "blood", Left: "wash this ", LineNumber: 2, Right: " clean fro", Title: "TitleA", WorkID: "1"},
+			},
+		},
+		{
+			name:    "specific work",
+			options: ConcordanceOptions{Term: "blood", Width: 5, WorkID: "1"},
+			expected: []ConcordanceLine{
+				{Keyword: "blood", Left: "this ", LineNumber: 2, Right: " clea", Title: "TitleA", WorkID: "1"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			concordance, err := searcher.Concordance(tc.options)
+			assert.Nil(t, err)
+			assert.Equal(t, len(tc.expected), concordance.Meta.TotalResults)
+			assert.Equal(t, tc.expected, concordance.Data)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sankt-petersbug/shakesearch/prosody"
+)
+
+// token is a word of a line with its byte offsets in the line and its position
+// among the words of the line (starting at 1)
+type token struct {
+	term     string
+	start    int
+	end      int
+	position int
+}
+
+// occurrenceFunc calls fn in text order for every line of a work (or of every work
+// if workID is empty) matching a term, with the tokens of the term sorted by position
+type occurrenceFunc func(term string, workID string, fn func(docID string, doc Document, tokens []token) error) error
+
+// corpus holds the works and lines indexed by a store, and implements the features
+// computed from them rather than from the index of the store
+type corpus struct {
+	// generation counts the calls to addWorks done, keying the cached results so the
+	// ones computed while indexing are not reused (first field for atomic alignment)
+	generation uint64
+
+	works *sync.Map // works keyed by workKey
+	lines *sync.Map // lines keyed by document id
+	cache *sync.Map // results derived from the indexed lines
+	// collocations caches the collocations of the most recently asked terms
+	collocations *lruCache
+	casts        *sync.Map // characters and speeches of the works keyed by workKey
+	grams        *trigramIndex
+	verse        *prosody.Analyzer // finds the meter and rhyme of lines
+
+	// tokenize splits a line into the lowercased, unstemmed words the store indexes
+	tokenize func(text string) []token
+	// occurrences finds the lines matching a term with the index of the store
+	occurrences occurrenceFunc
+
+	mu       sync.Mutex       // serializes indexing
+	docCount int              // number of lines indexed, so document ids stay unique across batches
+	docIDs   map[string][]int // numbers of the documents of each work keyed by workKey
+}
+
+func newCorpus(tokenize func(text string) []token) *corpus {
+	return &corpus{
+		works:        new(sync.Map),
+		lines:        new(sync.Map),
+		cache:        new(sync.Map),
+		collocations: newLRUCache(collocationCacheSize),
+		casts:        new(sync.Map),
+		grams:        newTrigramIndex(),
+		docIDs:       make(map[string][]int),
+		verse:        prosody.NewAnalyzer(nil),
+		tokenize:     tokenize,
+	}
+}
+
+// workKey returns the key of a work in an edition
+func workKey(id string, edition string) string {
+	return edition + "/" + id
+}
+
+// addWorks stores works and calls fn with each of their lines. Works without an edition
+// are in DefaultEdition, and a work replaces the one with the same ID and edition: remove
+// is called with each line of the work replaced before its new lines are added. Lines
+// of a work have consecutive document ids, and the caches are cleared once done.
+func (c *corpus) addWorks(data []ShakespeareWork, fn func(docID string, doc Document) error, remove func(docID string, doc Document) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.cache.Range(func(key, value interface{}) bool {
+		c.cache.Delete(key)
+		return true
+	})
+	defer c.collocations.Clear()
+	defer atomic.AddUint64(&c.generation, 1)
+
+	for _, work := range data {
+		if work.Edition == "" {
+			work.Edition = DefaultEdition
+		}
+		key := workKey(work.ID, work.Edition)
+		for _, n := range c.docIDs[key] {
+			docID := strconv.Itoa(n)
+			value, ok := c.lines.Load(docID)
+			if !ok {
+				continue
+			}
+			doc := value.(Document)
+			c.lines.Delete(docID)
+			c.grams.remove(n, doc.line())
+			if err := remove(docID, doc); err != nil {
+				return err
+			}
+		}
+		c.docIDs[key] = nil
+		c.works.Store(key, work)
+		c.casts.Store(key, readCast(work, c.tokenize))
+		structure := work.Structure()
+		blocks := structure.lineBlocks()
+		forms := structure.lineForms()
+		for _, line := range work.Lines() {
+			c.docCount++
+			docID := strconv.Itoa(c.docCount)
+			doc := Document{
+				Edition:    work.Edition,
+				Form:       forms[line.LineNumber],
+				Kind:       string(blocks[line.LineNumber].Kind),
+				LineNumber: toZeroPaddedString(line.LineNumber),
+				Meter:      c.verse.LineMeters(line.Text),
+				Rhyme:      c.verse.LineRhymeKey(line.Text),
+				Speaker:    blocks[line.LineNumber].Speaker,
+				Text:       line.Text,
+				Title:      work.Title,
+				WorkID:     work.ID,
+			}
+			if doc.Form == FormStage {
+				doc.StageDirection, doc.Text = doc.Text, ""
+			}
+			c.lines.Store(docID, doc)
+			c.grams.add(c.docCount, doc.line())
+			c.docIDs[key] = append(c.docIDs[key], c.docCount)
+			if err := fn(docID, doc); err != nil {
+				return err
+			}
+		}
+		log.Infof("Indexed: %s (%s), (%d docs)", work.Title, work.Edition, c.docCount)
+	}
+	return nil
+}
+
+// cacheKey returns the key of a cached result computed from the lines indexed so far,
+// to be taken before reading them
+func (c *corpus) cacheKey(key string) string {
+	return strconv.FormatUint(atomic.LoadUint64(&c.generation), 10) + ":" + key
+}
+
+// prepare validates the options of a search and returns them with the rhyme key of
+// their rhyme word and a trimmed query
+func (c *corpus) prepare(options SearchOptions) (SearchOptions, error) {
+	if err := validateMode(options); err != nil {
+		return options, err
+	}
+	if !isTextSearch(options) {
+		// a blank query has no words, so it matches every line like an empty one
+		options.Query = strings.TrimSpace(options.Query)
+	}
+	if options.Form != "" && !forms[options.Form] {
+		return options, invalidOptions("invalid form: %s", options.Form)
+	}
+	if err := validateIn(options); err != nil {
+		return options, err
+	}
+	if err := validateMeter(options); err != nil {
+		return options, err
+	}
+	options.rhymeKey = ""
+	if options.Rhyme != "" {
+		options.rhymeKey = c.verse.RhymeKey(options.Rhyme)
+		if options.rhymeKey == "" {
+			return options, invalidOptions("no rhyme for %s", options.Rhyme)
+		}
+	}
+	return options, nil
+}
+
+// filter reports whether a document meets the filters of the options
+func filter(options SearchOptions, doc Document) bool {
+	if options.WorkID != "" && doc.WorkID != options.WorkID {
+		return false
+	}
+	if options.Edition != "" && doc.Edition != options.Edition {
+		return false
+	}
+	if (doc.StageDirection != "") != inStage(options) {
+		return false
+	}
+	if options.Form != "" && doc.Form != options.Form {
+		return false
+	}
+	if options.Meter != "" && !containsString(doc.Meter, options.Meter) {
+		return false
+	}
+	if options.rhymeKey != "" && doc.Rhyme != options.rhymeKey {
+		return false
+	}
+	if ids := options.Filters.WorkIDs; len(ids) > 0 {
+		found := false
+		for _, id := range ids {
+			found = found || id == doc.WorkID
+		}
+		if !found {
+			return false
+		}
+	}
+	if r := options.Filters.LineNumber; r != nil {
+		lineNumber, _ := parseZeroPaddedNumber(doc.LineNumber)
+		if (r.From > 0 && lineNumber < r.From) || (r.To > 0 && lineNumber > r.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// documentField returns the value of an indexed field of a document
+func documentField(doc Document, field string) string {
+	switch field {
+	case "Edition":
+		return doc.Edition
+	case "Form":
+		return doc.Form
+	case "Kind":
+		return doc.Kind
+	case "LineNumber":
+		return doc.LineNumber
+	case "Meter":
+		return strings.Join(doc.Meter, " ")
+	case "Rhyme":
+		return doc.Rhyme
+	case "Speaker":
+		return doc.Speaker
+	case "StageDirection":
+		return doc.StageDirection
+	case "Text":
+		return doc.Text
+	case "Title":
+		return doc.Title
+	case "WorkID":
+		return doc.WorkID
+	}
+	return ""
+}
+
+// documentValues returns the values of an indexed field of a document, several for
+// multi-valued fields
+func documentValues(doc Document, field string) []string {
+	if field == "Meter" {
+		return doc.Meter
+	}
+	if value := documentField(doc, field); value != "" {
+		return []string{value}
+	}
+	return nil
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// scoredLine is a line matching a search found without the index of a store
+type scoredLine struct {
+	id    string
+	doc   Document
+	score float64
+}
+
+// lessLines returns the order of lines sorted by fields (prefix - to desc.), by score if there is none
+func lessLines(sortBy []string) func(a, b scoredLine) bool {
+	if len(sortBy) == 0 {
+		sortBy = []string{"-_score"}
+	}
+	return func(a, b scoredLine) bool {
+		for _, field := range sortBy {
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			var cmp int
+			switch field {
+			case "_score":
+				switch {
+				case a.score < b.score:
+					cmp = -1
+				case a.score > b.score:
+					cmp = 1
+				}
+			case "_id":
+				cmp = strings.Compare(a.id, b.id)
+			default:
+				cmp = strings.Compare(documentField(a.doc, field), documentField(b.doc, field))
+			}
+			if desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	}
+}
+
+// countFacets counts the values of the facet fields in the lines
+func countFacets(lines []scoredLine, facets map[string]int) (map[string][]FacetCount, error) {
+	if len(facets) == 0 {
+		return nil, nil
+	}
+	result := make(map[string][]FacetCount, len(facets))
+	for name, size := range facets {
+		field, err := facetField(name, size)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int)
+		for _, line := range lines {
+			for _, value := range documentValues(line.doc, field) {
+				counts[value]++
+			}
+		}
+		terms := make([]FacetCount, 0, len(counts))
+		for term, count := range counts {
+			terms = append(terms, FacetCount{Count: count, Term: term})
+		}
+		sort.Slice(terms, func(i, j int) bool {
+			if terms[i].Count != terms[j].Count {
+				return terms[i].Count > terms[j].Count
+			}
+			return terms[i].Term < terms[j].Term
+		})
+		if len(terms) > size {
+			terms = terms[:size]
+		}
+		result[name] = terms
+	}
+	return result, nil
+}
+
+// newHit returns the hit of a line with the spans of its text (byte offsets, in order)
+// marked as highlighted
+func newHit(line scoredLine, spans [][]int) Hit {
+	var text strings.Builder
+	last := 0
+	content := line.doc.line()
+	for _, span := range spans {
+		text.WriteString(content[last:span[0]])
+		text.WriteString("<mark>" + content[span[0]:span[1]] + "</mark>")
+		last = span[1]
+	}
+	text.WriteString(content[last:])
+	lineNumber, _ := parseZeroPaddedNumber(line.doc.LineNumber)
+	return Hit{
+		Edition:    line.doc.Edition,
+		Line:       text.String(),
+		LineNumber: lineNumber,
+		Score:      line.score,
+		Title:      line.doc.Title,
+		WorkID:     line.doc.WorkID,
+	}
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DiffOp is the change of a line between two editions
+type DiffOp string
+
+const (
+	// DiffEqual is a line with the same text in both editions
+	DiffEqual DiffOp = "equal"
+	// DiffVariant is a line with a different reading, e.g. spelling or punctuation
+	DiffVariant DiffOp = "variant"
+	// DiffInsert is a line only in the edition compared to
+	DiffInsert DiffOp = "insert"
+	// DiffDelete is a line only in the edition compared from
+	DiffDelete DiffOp = "delete"
+)
+
+// maxVariantDistance is the maximum edit distance between the normalized texts of two
+// lines read as variants, in tenths of the length of the longest one
+const maxVariantDistance = 3
+
+// DiffLine represents a line aligned between two editions
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	From *Line  `json:"from,omitempty"` // nil for insertions
+	To   *Line  `json:"to,omitempty"`   // nil for deletions
+}
+
+// DiffSummary counts the lines of a diff by change
+type DiffSummary struct {
+	Delete  int `json:"delete"`
+	Equal   int `json:"equal"`
+	Insert  int `json:"insert"`
+	Variant int `json:"variant"`
+}
+
+// Diff represents the lines of a work aligned between two editions
+type Diff struct {
+	WorkID  string      `json:"workId"`
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Lines   []DiffLine  `json:"lines"`
+	Summary DiffSummary `json:"summary"`
+}
+
+// normalizeLine returns the lowercased words of a line without punctuation, which
+// lines of two editions must share to be aligned
+func normalizeLine(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}), " ")
+}
+
+func isVariant(a, b string) bool {
+	longest := len([]rune(a))
+	if n := len([]rune(b)); n > longest {
+		longest = n
+	}
+	return levenshtein(a, b)*10 <= longest*maxVariantDistance
+}
+
+// differ finds the longest common subsequence of two sequences with the linear space
+// variant of Myers' algorithm ("An O(ND) Difference Algorithm and Its Variations")
+type differ struct {
+	a, b    []string
+	matches [][2]int // indexes of the equal elements of a and b
+}
+
+func (d *differ) match(i, j int) {
+	d.matches = append(d.matches, [2]int{i, j})
+}
+
+// middleSnake returns the start and end of the middle snake of the shortest edit
+// script of a[aLo:aHi] and b[bLo:bHi]
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int, int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// furthest x reached on each diagonal k = x - y, forwards and backwards from the ends
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for depth := 0; depth <= max; depth++ {
+		for k := -depth; k <= depth; k += 2 {
+			var x int
+			if k == -depth || (k != depth && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if r := delta - k; odd && r >= -(depth-1) && r <= depth-1 && x+backward[offset+r] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y
+			}
+		}
+		for r := -depth; r <= depth; r += 2 {
+			var x int
+			if r == -depth || (r != depth && backward[offset+r-1] < backward[offset+r+1]) {
+				x = backward[offset+r+1]
+			} else {
+				x = backward[offset+r-1] + 1
+			}
+			y := x - r
+			startX, startY := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[offset+r] = x
+			if k := delta - r; !odd && k >= -depth && k <= depth && forward[offset+k]+x >= n {
+				return aLo + n - x, bLo + m - y, aLo + n - startX, bLo + m - startY
+			}
+		}
+	}
+	// not reached: the paths overlap at the latest when depth is max
+	return aLo, bLo, aLo, bLo
+}
+
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.match(aLo, bLo)
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		d.match(aHi, bHi)
+	}
+	if aLo == aHi || bLo == bHi {
+		return
+	}
+	x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+	for i := 0; x+i < u; i++ {
+		d.match(x+i, y+i)
+	}
+	d.compare(aLo, x, bLo, y)
+	d.compare(u, aHi, v, bHi)
+}
+
+// commonLines returns the indexes of the lines of a and b in their longest common subsequence
+func commonLines(a, b []string) [][2]int {
+	d := &differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	sort.Slice(d.matches, func(i, j int) bool {
+		return d.matches[i][0] < d.matches[j][0]
+	})
+	return d.matches
+}
+
+// alignChanged aligns the lines removed and added between two common lines. A removed
+// line close enough to the added line at the same position is a variant of it.
+func alignChanged(removed, added []Line) []DiffLine {
+	var lines []DiffLine
+	for i := 0; i < len(removed) || i < len(added); i++ {
+		switch {
+		case i < len(removed) && i < len(added) && isVariant(normalizeLine(removed[i].Text), normalizeLine(added[i].Text)):
+			lines = append(lines, DiffLine{Op: DiffVariant, From: &removed[i], To: &added[i]})
+		default:
+			if i < len(removed) {
+				lines = append(lines, DiffLine{Op: DiffDelete, From: &removed[i]})
+			}
+			if i < len(added) {
+				lines = append(lines, DiffLine{Op: DiffInsert, To: &added[i]})
+			}
+		}
+	}
+	return lines
+}
+
+// diffLines aligns the lines of two editions. Lines with the same words are aligned
+// first, and lines differing only by case or punctuation are variants.
+func diffLines(from, to []Line) []DiffLine {
+	a := make([]string, len(from))
+	for i, line := range from {
+		a[i] = normalizeLine(line.Text)
+	}
+	b := make([]string, len(to))
+	for i, line := range to {
+		b[i] = normalizeLine(line.Text)
+	}
+
+	lines := make([]DiffLine, 0, len(from))
+	i, j := 0, 0
+	for _, m := range append(commonLines(a, b), [2]int{len(from), len(to)}) {
+		lines = append(lines, alignChanged(from[i:m[0]], to[j:m[1]])...)
+		if m[0] == len(from) {
+			break
+		}
+		op := DiffEqual
+		if strings.TrimSpace(from[m[0]].Text) != strings.TrimSpace(to[m[1]].Text) {
+			op = DiffVariant
+		}
+		lines = append(lines, DiffLine{Op: op, From: &from[m[0]], To: &to[m[1]]})
+		i, j = m[0]+1, m[1]+1
+	}
+	return lines
+}
+
+// Diff aligns the lines of a work in two editions, numbered as they are indexed
+func (c *corpus) Diff(id string, from string, to string) (Diff, error) {
+	diff := Diff{WorkID: id, From: from, To: to}
+	fromWork, err := c.GetWorkByEdition(id, from)
+	if err != nil {
+		return diff, err
+	}
+	toWork, err := c.GetWorkByEdition(id, to)
+	if err != nil {
+		return diff, err
+	}
+
+	diff.Lines = diffLines(fromWork.Lines(), toWork.Lines())
+	for _, line := range diff.Lines {
+		switch line.Op {
+		case DiffEqual:
+			diff.Summary.Equal++
+		case DiffVariant:
+			diff.Summary.Variant++
+		case DiffInsert:
+			diff.Summary.Insert++
+		case DiffDelete:
+			diff.Summary.Delete++
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lcsLength returns the length of the longest common subsequence by dynamic programming
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		curr := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				curr[j+1] = prev[j] + 1
+			} else if prev[j+1] > curr[j] {
+				curr[j+1] = prev[j+1]
+			} else {
+				curr[j+1] = curr[j]
+			}
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+func TestCommonLines(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	sequence := func() []string {
+		s := make([]string, random.Intn(30))
+		for i := range s {
+			s[i] = string(rune('a' + random.Intn(3)))
+		}
+		return s
+	}
+
+	for n := 0; n < 500; n++ {
+		a, b := sequence(), sequence()
+		matches := commonLines(a, b)
+		if !assert.Len(t, matches, lcsLength(a, b), "%v %v", a, b) {
+			return
+		}
+		for i, m := range matches {
+			assert.Equal(t, a[m[0]], b[m[1]])
+			if i > 0 {
+				assert.True(t, m[0] > matches[i-1][0] && m[1] > matches[i-1][1], "%v %v", a, b)
+			}
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	from := []Line{
+		{LineNumber: 1, Text: "The quality of mercy is not strain'd,"},
+		{LineNumber: 2, Text: "It droppeth as the gentle rain from heaven"},
+		{LineNumber: 4, Text: "Upon the place beneath."},
+		{LineNumber: 5, Text: "It is twice blest;"},
+	}
+	to := []Line{
+		{LineNumber: 1, Text: "The quality of mercie is not strain'd,"},
+		{LineNumber: 2, Text: "It droppeth as the gentle rain from heaven"},
+		{LineNumber: 3, Text: "UPON THE PLACE BENEATH:"},
+		{LineNumber: 4, Text: "It is twice blest,"},
+		{LineNumber: 5, Text: "It blesseth him that gives, and him that takes,"},
+	}
+
+	expected := []DiffLine{
+		{Op: DiffVariant, From: &from[0], To: &to[0]},
+		{Op: DiffEqual, From: &from[1], To: &to[1]},
+		{Op: DiffVariant, From: &from[2], To: &to[2]},
+		{Op: DiffVariant, From: &from[3], To: &to[3]},
+		{Op: DiffInsert, To: &to[4]},
+	}
+	assert.Equal(t, expected, diffLines(from, to))
+}
+
+func TestDiffLines_Changed(t *testing.T) {
+	from := []Line{
+		{LineNumber: 1, Text: "first"},
+		{LineNumber: 2, Text: "a line removed from the text"},
+		{LineNumber: 3, Text: "last"},
+	}
+	to := []Line{
+		{LineNumber: 1, Text: "first"},
+		{LineNumber: 2, Text: "something else entirely"},
+		{LineNumber: 3, Text: "last"},
+	}
+
+	expected := []DiffLine{
+		{Op: DiffEqual, From: &from[0], To: &to[0]},
+		{Op: DiffDelete, From: &from[1]},
+		{Op: DiffInsert, To: &to[1]},
+		{Op: DiffEqual, From: &from[2], To: &to[2]},
+	}
+	assert.Equal(t, expected, diffLines(from, to))
+}
+
+func TestBleveStore_Diff(t *testing.T) {
+	searcher := newTestStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "first\n\nsecond"},
+		{ID: "1", Edition: "folio", Title: "TitleA", Content: "first\nsecond\nthird"},
+	})
+
+	diff, err := searcher.Diff("1", DefaultEdition, "folio")
+	assert.Nil(t, err)
+	assert.Equal(t, DiffSummary{Equal: 2, Insert: 1}, diff.Summary)
+	assert.Equal(t, 3, diff.Lines[1].From.LineNumber)
+	assert.Equal(t, 2, diff.Lines[1].To.LineNumber)
+
+	_, err = searcher.Diff("1", DefaultEdition, "quarto")
+	assert.Equal(t, ErrWorkNotFound, err)
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// FormVerse is a line of verse
+	FormVerse = "verse"
+	// FormProse is a line of prose
+	FormProse = "prose"
+	// FormSong is a line of a song, set apart from the speech around it
+	FormSong = "song"
+	// FormStage is a line of a stage direction
+	FormStage = "stage"
+)
+
+const (
+	// InText searches the spoken or printed text of the lines, the default
+	InText = "text"
+	// InStage searches the stage directions
+	InStage = "stage"
+)
+
+const (
+	// proseLength is the length (in characters) from which a line is likely prose, as
+	// prose runs to the width of the page while verse lines seldom reach it
+	proseLength = 65
+	// songLength is the maximum length of the lines of a song
+	songLength = 45
+	// minSongLines is the minimum number of lines of a song
+	minSongLines = 3
+)
+
+// forms are the forms of lines searches can be filtered by
+var forms = map[string]bool{
+	FormVerse: true,
+	FormProse: true,
+	FormSong:  true,
+	FormStage: true,
+}
+
+// startsLowercase reports whether the first letter of a line is lowercase, which
+// happens in prose wrapped at the width of the page but not in verse
+func startsLowercase(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return unicode.IsLower(r)
+		}
+	}
+	return false
+}
+
+// isSong reports whether indented lines are a song rather than a stage direction: at
+// least minSongLines short lines starting with a capital, none reading as a direction
+func isSong(lines []Line) bool {
+	if len(lines) < minSongLines {
+		return false
+	}
+	for _, line := range lines {
+		text := strings.TrimSpace(line.Text)
+		if utf8.RuneCountInString(text) > songLength || startsLowercase(text) || stagePattern.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// blockForm returns the form of the lines of a block. Stage blocks are set apart by
+// their indentation, and the indented ones looking like verse are songs. Speeches and
+// text are prose if a line starts with a lowercase letter or runs to the width of the page.
+func blockForm(block Block) string {
+	switch block.Kind {
+	case BlockStage:
+		if isSong(block.Lines) {
+			return FormSong
+		}
+		return FormStage
+	case BlockSpeech, BlockText:
+		for _, line := range block.Lines {
+			text := strings.TrimSpace(line.Text)
+			if startsLowercase(text) || utf8.RuneCountInString(text) >= proseLength {
+				return FormProse
+			}
+		}
+		return FormVerse
+	}
+	return ""
+}
+
+// lineForms returns the form of each line in a block keyed by line number. Headings
+// have none.
+func (s Structure) lineForms() map[int]string {
+	lineForms := make(map[int]string)
+	s.eachBlock(func(block Block) {
+		form := blockForm(block)
+		for _, line := range block.Lines {
+			lineForms[line.LineNumber] = form
+		}
+	})
+	return lineForms
+}
+
+// inStage reports whether a search is of stage directions. Searches of the stage form
+// are, unless they ask for the text.
+func inStage(options SearchOptions) bool {
+	return options.In == InStage || (options.In == "" && options.Form == FormStage)
+}
+
+// validateIn returns an error if the lines searched by the options are unknown or
+// cannot have their form
+func validateIn(options SearchOptions) error {
+	switch options.In {
+	case "", InText, InStage:
+	default:
+		return invalidOptions("invalid in: %s", options.In)
+	}
+	if options.Form != "" && options.Form != FormStage && inStage(options) {
+		return invalidOptions("stage directions have no form %s", options.Form)
+	}
+	if options.Form == FormStage && !inStage(options) {
+		return invalidOptions("stage form is only searched in stage directions")
+	}
+	return nil
+}
+
+// textField returns the field of the index searched by the options
+func textField(options SearchOptions) string {
+	if inStage(options) {
+		return "StageDirection"
+	}
+	return "Text"
+}
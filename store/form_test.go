@@ -0,0 +1,202 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testFormPlay = `ACT I
+
+SCENE I. A street.
+
+ Enter Touchstone and Audrey.
+
+TOUCHSTONE.
+Come apace, good Audrey. I will fetch up your goats, Audrey. And how,
+Audrey, am I the man yet? Doth my simple feature content you?
+
+AUDREY.
+Your features, Lord warrant us! What features?
+
+AMIENS.
+Under the greenwood tree
+Who loves to lie with me,
+
+  Here shall he see
+  No enemy
+  But winter and rough weather.
+
+ [_Exeunt._]`
+
+func TestBlockForm(t *testing.T) {
+	testCases := []struct {
+		name     string
+		block    Block
+		expected string
+	}{
+		{name: "heading", block: Block{}, expected: ""},
+		{
+			name:     "stage",
+			block:    Block{Kind: BlockStage, Lines: []Line{{Text: " Enter Touchstone and Audrey."}}},
+			expected: FormStage,
+		},
+		{
+			name: "stage without keyword",
+			block: Block{Kind: BlockStage, Lines: []Line{
+				{Text: " Alarum. Excursions. Enter the King, Prince John of Lancaster,"},
+				{Text: " Earl of Westmoreland, and others"},
+			}},
+			expected: FormStage,
+		},
+		{
+			name: "song",
+			block: Block{Kind: BlockStage, Lines: []Line{
+				{Text: "  Here shall he see"}, {Text: "  No enemy"}, {Text: "  But winter and rough weather."},
+			}},
+			expected: FormSong,
+		},
+		{
+			name: "verse",
+			block: Block{Kind: BlockSpeech, Lines: []Line{
+				{Text: "When shall we three meet again?"}, {Text: "In thunder, lightning, or in rain?"},
+			}},
+			expected: FormVerse,
+		},
+		{
+			name: "prose continuation",
+			block: Block{Kind: BlockSpeech, Lines: []Line{
+				{Text: "I will fetch up your goats, Audrey. And how, Audrey, am I the"},
+				{Text: "man yet?"},
+			}},
+			expected: FormProse,
+		},
+		{
+			name: "long line",
+			block: Block{Kind: BlockText, Lines: []Line{
+				{Text: "Come apace, good Audrey. I will fetch up your goats, Audrey. And how,"},
+				{Text: "Audrey, am I the man yet?"},
+			}},
+			expected: FormProse,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, blockForm(tC.block))
+		})
+	}
+}
+
+func TestStructure_LineForms(t *testing.T) {
+	forms := ShakespeareWork{Content: testFormPlay}.Structure().lineForms()
+	assert.Equal(t, map[int]string{
+		5:  FormStage,
+		8:  FormProse,
+		9:  FormProse,
+		12: FormVerse,
+		15: FormVerse,
+		16: FormVerse,
+		18: FormSong,
+		19: FormSong,
+		20: FormSong,
+		22: FormStage,
+	}, forms)
+}
+
+func TestStores_Form(t *testing.T) {
+	data := []ShakespeareWork{{ID: "1", Title: "TitleA", Content: testFormPlay}}
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		result, err := s.Search(SearchOptions{
+			Query:      "audrey",
+			Form:       FormProse,
+			PageNumber: 1,
+			PageSize:   10,
+			SortBy:     []string{"LineNumber"},
+			Facets:     map[string]int{"form": 10},
+		})
+		assert.Nil(t, err)
+		var lineNumbers []int
+		for _, hit := range result.Data {
+			lineNumbers = append(lineNumbers, hit.LineNumber)
+		}
+		assert.Equal(t, []int{8, 9}, lineNumbers)
+		assert.Equal(t, []FacetCount{{Count: 2, Term: FormProse}}, result.Meta.Facets["form"])
+
+		result, err = s.Search(SearchOptions{PageNumber: 1, PageSize: 10, Facets: map[string]int{"form": 10}})
+		assert.Nil(t, err)
+		assert.Equal(t, []FacetCount{
+			{Count: 3, Term: FormSong},
+			{Count: 3, Term: FormVerse},
+			{Count: 2, Term: FormProse},
+		}, result.Meta.Facets["form"], "stage directions are only searched with in=stage")
+
+		_, err = s.Search(SearchOptions{PageNumber: 1, PageSize: 10, Form: "poetry"})
+		assert.NotNil(t, err)
+	})
+}
+
+const testStagePlay = `ACT I
+
+SCENE I. Bohemia. A desert country near the sea.
+
+ANTIGONUS.
+This is the chase: I am gone for ever.
+
+ [_Exit, pursued by a bear._]
+
+ Enter an old Shepherd.
+
+SHEPHERD.
+They have scared away two of my best sheep, which I fear the wolf will sooner find than the bear.`
+
+func TestStores_StageDirections(t *testing.T) {
+	data := []ShakespeareWork{{ID: "1", Title: "TitleA", Content: testStagePlay}}
+	testCases := []struct {
+		desc     string
+		options  SearchOptions
+		expected []int
+	}{
+		{desc: "text", options: SearchOptions{Query: "bear"}, expected: []int{13}},
+		{desc: "stage", options: SearchOptions{Query: "every exit pursued by a bear", In: InStage}, expected: []int{8}},
+		{desc: "every stage direction", options: SearchOptions{In: InStage}, expected: []int{8, 10}},
+		{desc: "stage form", options: SearchOptions{Form: FormStage}, expected: []int{8, 10}},
+		{desc: "text without query", options: SearchOptions{In: InText}, expected: []int{1, 3, 5, 6, 12, 13}},
+		{desc: "substring", options: SearchOptions{Query: "bear", Mode: ModeSubstring}, expected: []int{13}},
+		{desc: "stage substring", options: SearchOptions{Query: "bear", Mode: ModeSubstring, In: InStage}, expected: []int{8}},
+		{
+			desc:     "stage structured",
+			options:  SearchOptions{Structured: &QueryClause{Phrase: &PhraseClause{Text: "pursued by a bear"}}, In: InStage},
+			expected: []int{8},
+		},
+	}
+
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		for _, tC := range testCases {
+			t.Run(tC.desc, func(t *testing.T) {
+				tC.options.PageNumber = 1
+				tC.options.PageSize = 10
+				tC.options.SortBy = []string{"LineNumber"}
+				result, err := s.Search(tC.options)
+				assert.Nil(t, err)
+				var lineNumbers []int
+				for _, hit := range result.Data {
+					lineNumbers = append(lineNumbers, hit.LineNumber)
+				}
+				assert.Equal(t, tC.expected, lineNumbers)
+			})
+		}
+		t.Run("invalid", func(t *testing.T) {
+			for _, options := range []SearchOptions{
+				{In: "speech"},
+				{In: InText, Form: FormStage},
+				{In: InStage, Form: FormVerse},
+			} {
+				options.PageNumber = 1
+				options.PageSize = 10
+				_, err := s.Search(options)
+				assert.True(t, errors.Is(err, ErrInvalidSearchOptions))
+			}
+		})
+	})
+}
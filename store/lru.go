@@ -0,0 +1,73 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache is a cache of at most capacity values, evicting the least recently used one
+// when full. It keeps results computed from user input, which would grow without
+// bound in the cache of the corpus.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // entries from the most to the least recently used
+	items    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value of a key and marks it as the most recently used
+func (l *lruCache) Get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruEntry).value, true
+}
+
+// Add sets the value of a key, evicting the least recently used value if the cache is full
+func (l *lruCache) Add(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.items[key]; ok {
+		element.Value.(*lruEntry).value = value
+		l.order.MoveToFront(element)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, value: value})
+	if l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Clear removes every value
+func (l *lruCache) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.items = make(map[string]*list.Element)
+}
+
+// Len returns the number of values
+func (l *lruCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
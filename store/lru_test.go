@@ -0,0 +1,31 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	cache := newLRUCache(2)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	_, ok := cache.Get("a") // b is now the least recently used
+	assert.True(t, ok)
+	cache.Add("c", 3)
+	assert.Equal(t, 2, cache.Len())
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	cache.Add("c", 4)
+	value, _ = cache.Get("c")
+	assert.Equal(t, 4, value)
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Len())
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+}
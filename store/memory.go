@@ -0,0 +1,590 @@
+package store
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// memoryDoc is a line indexed by MemoryStore
+type memoryDoc struct {
+	id      string
+	doc     Document
+	length  int  // number of words
+	removed bool // replaced by the line of another version of its work
+}
+
+// matches maps the index of the matching documents to their score
+type matches map[int]float64
+
+// MemoryStore is a store keeping an inverted index of the lines in memory. Unlike
+// BleveStore, words are neither stemmed nor filtered and hits are scored by TF-IDF.
+type MemoryStore struct {
+	*corpus
+	mu       sync.RWMutex
+	docs     []memoryDoc
+	ids      map[string]int           // index of each document id in docs
+	removed  int                      // number of removed documents
+	postings map[string]map[int][]int // positions of each word in each document
+	// stagePostings are the postings of the words of stage directions
+	stagePostings map[string]map[int][]int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// tokenizeWords splits text into lowercased words. Apostrophes are kept within words,
+// e.g. strain'd, but not at their start or end.
+func tokenizeWords(text string) []token {
+	var tokens []token
+	start := -1
+	for i := 0; i <= len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		inWord := i < len(text) && isWordRune(r)
+		if i < len(text) && start >= 0 && isApostrophe(r) {
+			next, _ := utf8.DecodeRuneInString(text[i+size:])
+			inWord = isWordRune(next)
+		}
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = append(tokens, token{
+				term:     strings.ToLower(text[start:i]),
+				start:    start,
+				end:      i,
+				position: len(tokens) + 1,
+			})
+			start = -1
+		}
+		if i == len(text) {
+			break
+		}
+		i += size
+	}
+	return tokens
+}
+
+// memoryBatchSize is the number of lines MemoryStore indexes at a time. Searches wait
+// for a batch, not for the whole BatchIndex.
+const memoryBatchSize = 1000
+
+// memoryOp is a line added to or removed from the index of MemoryStore
+type memoryOp struct {
+	docID  string
+	doc    Document
+	tokens []token
+	remove bool
+}
+
+// BatchIndex inserts and indexes a slice of ShakespeareWork. Works without an edition
+// are in DefaultEdition, and a work replaces the one with the same ID and edition.
+// Lines are tokenized outside of the lock, which is held for a batch of lines at a time.
+func (m *MemoryStore) BatchIndex(data []ShakespeareWork) error {
+	batch := make([]memoryOp, 0, memoryBatchSize)
+	flush := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, op := range batch {
+			if op.remove {
+				m.removeDoc(op)
+			} else {
+				m.addDoc(op)
+			}
+		}
+		batch = batch[:0]
+	}
+	add := func(op memoryOp) error {
+		op.tokens = m.tokenize(op.doc.line())
+		batch = append(batch, op)
+		if len(batch) >= memoryBatchSize {
+			flush()
+		}
+		return nil
+	}
+	err := m.addWorks(data, func(docID string, doc Document) error {
+		return add(memoryOp{docID: docID, doc: doc})
+	}, func(docID string, doc Document) error {
+		return add(memoryOp{docID: docID, doc: doc, remove: true})
+	})
+	flush()
+	return err
+}
+
+// addDoc indexes a line
+func (m *MemoryStore) addDoc(op memoryOp) {
+	n := len(m.docs)
+	m.docs = append(m.docs, memoryDoc{id: op.docID, doc: op.doc, length: len(op.tokens)})
+	m.ids[op.docID] = n
+	field := m.docPostings(op.doc)
+	for _, t := range op.tokens {
+		postings, ok := field[t.term]
+		if !ok {
+			postings = make(map[int][]int)
+			field[t.term] = postings
+		}
+		postings[n] = append(postings[n], t.position)
+	}
+}
+
+// removeDoc removes a line from the index
+func (m *MemoryStore) removeDoc(op memoryOp) {
+	n, ok := m.ids[op.docID]
+	if !ok {
+		return
+	}
+	field := m.docPostings(op.doc)
+	for _, t := range op.tokens {
+		delete(field[t.term], n)
+		if len(field[t.term]) == 0 {
+			delete(field, t.term)
+		}
+	}
+	// documents are numbered by their index, so removed ones keep their place
+	m.docs[n] = memoryDoc{id: op.docID, removed: true}
+	delete(m.ids, op.docID)
+	m.removed++
+}
+
+// docPostings returns the postings of the field a document is indexed in
+func (m *MemoryStore) docPostings(doc Document) map[string]map[int][]int {
+	if doc.StageDirection != "" {
+		return m.stagePostings
+	}
+	return m.postings
+}
+
+// fieldPostings returns the postings of the lines searched by the options
+func (m *MemoryStore) fieldPostings(options SearchOptions) map[string]map[int][]int {
+	if inStage(options) {
+		return m.stagePostings
+	}
+	return m.postings
+}
+
+// idf returns the inverse document frequency of a word
+func (e *evaluator) idf(term string) float64 {
+	return 1 + math.Log(float64(len(e.m.docs)-e.m.removed)/float64(len(e.postings[term])+1))
+}
+
+// score returns the TF-IDF score of a word in a document
+func (e *evaluator) score(term string, doc int) float64 {
+	tf := math.Sqrt(float64(len(e.postings[term][doc])))
+	return tf * e.idf(term) / math.Sqrt(float64(e.m.docs[doc].length))
+}
+
+// evaluator finds the documents matching queries and the words to highlight in them
+type evaluator struct {
+	m         *MemoryStore
+	postings  map[string]map[int][]int // postings of the searched field
+	highlight map[string]bool
+}
+
+// expand returns the indexed words within the edit distance of a word
+func (e *evaluator) expand(word string, fuzziness int) []string {
+	if fuzziness == 0 {
+		return []string{word}
+	}
+	var words []string
+	length := utf8.RuneCountInString(word)
+	for term := range e.postings {
+		if abs(utf8.RuneCountInString(term)-length) <= fuzziness && levenshtein(word, term) <= fuzziness {
+			words = append(words, term)
+		}
+	}
+	return words
+}
+
+// words returns the documents containing any of the words
+func (e *evaluator) words(words []string) matches {
+	result := make(matches)
+	for _, word := range words {
+		for doc := range e.postings[word] {
+			result[doc] += e.score(word, doc)
+			e.highlight[word] = true
+		}
+	}
+	return result
+}
+
+// match returns the documents containing any, or all if and is true, of the words of text
+func (e *evaluator) match(text string, fuzziness int, and bool) matches {
+	var result matches
+	for i, t := range e.m.tokenize(text) {
+		found := e.words(e.expand(t.term, fuzziness))
+		switch {
+		case i == 0:
+			result = found
+		case and:
+			result = intersect(result, found)
+		default:
+			result = union(result, found)
+		}
+	}
+	if result == nil {
+		return make(matches)
+	}
+	return result
+}
+
+// phrase returns the documents containing the words of text next to each other
+func (e *evaluator) phrase(text string) matches {
+	tokens := e.m.tokenize(text)
+	result := make(matches)
+	if len(tokens) == 0 {
+		return result
+	}
+	for doc, positions := range e.postings[tokens[0].term] {
+	next:
+		for _, pos := range positions {
+			for i, t := range tokens[1:] {
+				if !containsInt(e.postings[t.term][doc], pos+i+1) {
+					continue next
+				}
+			}
+			for _, t := range tokens {
+				result[doc] += e.score(t.term, doc)
+			}
+			break
+		}
+	}
+	if len(result) > 0 {
+		for _, t := range tokens {
+			e.highlight[t.term] = true
+		}
+	}
+	return result
+}
+
+// prefix returns the documents containing a word starting with prefix
+func (e *evaluator) prefix(prefix string) matches {
+	var words []string
+	for term := range e.postings {
+		if strings.HasPrefix(term, prefix) {
+			words = append(words, term)
+		}
+	}
+	return e.words(words)
+}
+
+// all returns every document with the same score
+func (e *evaluator) all() matches {
+	result := make(matches, len(e.m.docs)-e.m.removed)
+	for doc, d := range e.m.docs {
+		if !d.removed {
+			result[doc] = 1
+		}
+	}
+	return result
+}
+
+func (e *evaluator) clauses(clauses []QueryClause) ([]matches, error) {
+	var results []matches
+	for _, clause := range clauses {
+		result, err := e.clause(clause)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (e *evaluator) boolean(b *BoolClause) (matches, error) {
+	if len(b.Must)+len(b.Should)+len(b.MustNot) == 0 {
+		return nil, invalidOptions("bool query requires at least one clause")
+	}
+	must, err := e.clauses(b.Must)
+	if err != nil {
+		return nil, err
+	}
+	should, err := e.clauses(b.Should)
+	if err != nil {
+		return nil, err
+	}
+	// words of excluded lines are not highlighted
+	mustNot, err := (&evaluator{m: e.m, postings: e.postings, highlight: make(map[string]bool)}).clauses(b.MustNot)
+	if err != nil {
+		return nil, err
+	}
+
+	var result matches
+	switch {
+	case len(must) > 0:
+		result = must[0]
+		for _, r := range must[1:] {
+			result = intersect(result, r)
+		}
+		for _, r := range should {
+			for doc, score := range r {
+				if _, ok := result[doc]; ok {
+					result[doc] += score
+				}
+			}
+		}
+	case len(should) > 0:
+		result = should[0]
+		for _, r := range should[1:] {
+			result = union(result, r)
+		}
+	default:
+		result = e.all()
+	}
+	for _, r := range mustNot {
+		for doc := range r {
+			delete(result, doc)
+		}
+	}
+	return result, nil
+}
+
+func (e *evaluator) clause(q QueryClause) (matches, error) {
+	var results []matches
+	if q.Bool != nil {
+		result, err := e.boolean(q.Bool)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if q.Match != nil {
+		if q.Match.Text == "" {
+			return nil, invalidOptions("match query requires text")
+		}
+		if q.Match.Fuzziness < 0 || q.Match.Fuzziness > maxFuzziness {
+			return nil, invalidOptions("fuzziness must be between 0 and %d", maxFuzziness)
+		}
+		switch q.Match.Operator {
+		case "", "or", "and":
+		default:
+			return nil, invalidOptions("invalid match operator: %s", q.Match.Operator)
+		}
+		results = append(results, e.match(q.Match.Text, q.Match.Fuzziness, q.Match.Operator == "and"))
+	}
+	if q.Phrase != nil {
+		if q.Phrase.Text == "" {
+			return nil, invalidOptions("phrase query requires text")
+		}
+		results = append(results, e.phrase(q.Phrase.Text))
+	}
+	if q.Prefix != nil {
+		if q.Prefix.Prefix == "" {
+			return nil, invalidOptions("prefix query requires a prefix")
+		}
+		results = append(results, e.prefix(strings.ToLower(q.Prefix.Prefix)))
+	}
+	if len(results) != 1 {
+		return nil, invalidOptions("a query clause must have exactly one of bool, match, phrase or prefix")
+	}
+	return results[0], nil
+}
+
+func intersect(a, b matches) matches {
+	result := make(matches)
+	for doc, score := range a {
+		if other, ok := b[doc]; ok {
+			result[doc] = score + other
+		}
+	}
+	return result
+}
+
+func union(a, b matches) matches {
+	result := make(matches, len(a)+len(b))
+	for doc, score := range a {
+		result[doc] = score
+	}
+	for doc, score := range b {
+		result[doc] += score
+	}
+	return result
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the documents matching the options in their sort order, and the words
+// to highlight in them
+func (m *MemoryStore) find(options SearchOptions) ([]scoredLine, map[string]bool, error) {
+	e := &evaluator{m: m, postings: m.fieldPostings(options), highlight: make(map[string]bool)}
+	var result matches
+	if options.Query != "" {
+		for _, term := range strings.Fields(options.Query) {
+			result = union(result, e.match(term, options.Fuzziness, false))
+		}
+	}
+	if options.Structured != nil {
+		structured, err := e.clause(*options.Structured)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result == nil {
+			result = structured
+		} else {
+			result = intersect(result, structured)
+		}
+	}
+	if result == nil {
+		result = e.all()
+	}
+
+	docs := make([]scoredLine, 0, len(result))
+	for doc, score := range result {
+		if filter(options, m.docs[doc].doc) {
+			docs = append(docs, scoredLine{id: m.docs[doc].id, doc: m.docs[doc].doc, score: score})
+		}
+	}
+	less := lessLines(options.SortBySlice())
+	sort.Slice(docs, func(i, j int) bool {
+		return less(docs[i], docs[j])
+	})
+	return docs, e.highlight, nil
+}
+
+// hit returns the hit of a line with the highlighted words marked
+func (m *MemoryStore) hit(line scoredLine, highlight map[string]bool) Hit {
+	var spans [][]int
+	for _, t := range m.tokenize(line.doc.line()) {
+		if highlight[t.term] {
+			spans = append(spans, []int{t.start, t.end})
+		}
+	}
+	return newHit(line, spans)
+}
+
+// Search searches indexed documents using the search options provided
+func (m *MemoryStore) Search(options SearchOptions) (SearchResult, error) {
+	options, err := m.prepare(options)
+	if err != nil {
+		return newSearchResult(options), err
+	}
+	if isTextSearch(options) {
+		return m.searchText(options)
+	}
+	searchResult := newSearchResult(options)
+	m.mu.RLock()
+	docs, highlight, err := m.find(options)
+	if err == nil {
+		searchResult.Meta.Facets, err = countFacets(docs, options.Facets)
+	}
+	if err != nil {
+		m.mu.RUnlock()
+		return searchResult, err
+	}
+	searchResult.Meta.TotalResults = len(docs)
+	for i := options.Offset(); i < len(docs) && i < options.Offset()+options.PageSize; i++ {
+		searchResult.Data = append(searchResult.Data, m.hit(docs[i], highlight))
+	}
+	m.mu.RUnlock()
+
+	return m.withSuggestions(options, searchResult, m.dictionary, m.Search)
+}
+
+// Scan calls fn for every hit matching the options in their sort order, ignoring the
+// page options and facets. Scanning stops at the first error returned by fn.
+func (m *MemoryStore) Scan(options SearchOptions, fn func(Hit) error) error {
+	options, err := m.prepare(options)
+	if err != nil {
+		return err
+	}
+	if isTextSearch(options) {
+		return m.scanText(options, fn)
+	}
+	m.mu.RLock()
+	docs, highlight, err := m.find(options)
+	hits := make([]Hit, 0, len(docs))
+	for _, d := range docs {
+		hits = append(hits, m.hit(d, highlight))
+	}
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	for _, hit := range hits {
+		if err := fn(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dictionary visits the indexed words
+func (m *MemoryStore) dictionary(visit func(word string, count uint64)) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for term, postings := range m.postings {
+		count := 0
+		for _, positions := range postings {
+			count += len(positions)
+		}
+		visit(term, uint64(count))
+	}
+	return nil
+}
+
+// eachOccurrence calls fn in text order for every line containing a word of term along
+// with the occurrences of the words within the line
+func (m *MemoryStore) eachOccurrence(term string, workID string, fn func(docID string, doc Document, occurrences []token) error) error {
+	words := make(map[string]bool)
+	var docs []int
+	m.mu.RLock()
+	for _, t := range m.tokenize(term) {
+		words[t.term] = true
+		for doc := range m.postings[t.term] {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Ints(docs)
+	var lines []memoryDoc
+	for i, doc := range docs {
+		if (i == 0 || doc != docs[i-1]) && (workID == "" || m.docs[doc].doc.WorkID == workID) {
+			lines = append(lines, m.docs[doc])
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].doc.Title != lines[j].doc.Title {
+			return lines[i].doc.Title < lines[j].doc.Title
+		}
+		return lines[i].doc.LineNumber < lines[j].doc.LineNumber
+	})
+	for _, line := range lines {
+		var occurrences []token
+		for _, t := range m.tokenize(line.doc.Text) {
+			if words[t.term] {
+				occurrences = append(occurrences, t)
+			}
+		}
+		if err := fn(line.id, line.doc, occurrences); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewMemoryStore creates a new store indexing lines in memory
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		corpus:        newCorpus(tokenizeWords),
+		ids:           make(map[string]int),
+		postings:      make(map[string]map[int][]int),
+		stagePostings: make(map[string]map[int][]int),
+	}
+	s.occurrences = s.eachOccurrence
+	return s
+}
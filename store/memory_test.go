@@ -0,0 +1,241 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemoryStore(data []ShakespeareWork) *MemoryStore {
+	s := NewMemoryStore()
+	if err := s.BatchIndex(data); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestTokenizeWords(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected []token
+	}{
+		{text: "", expected: nil},
+		{
+			text: "Strain'd, the Quality!",
+			expected: []token{
+				{term: "strain'd", start: 0, end: 8, position: 1},
+				{term: "the", start: 10, end: 13, position: 2},
+				{term: "quality", start: 14, end: 21, position: 3},
+			},
+		},
+		{
+			text: "’Tis mercy’",
+			expected: []token{
+				{term: "tis", start: 3, end: 6, position: 1},
+				{term: "mercy", start: 7, end: 12, position: 2},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			assert.Equal(t, tC.expected, tokenizeWords(tC.text))
+		})
+	}
+}
+
+func TestMemoryStore_Search(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "to be or not to be\nthe rest is silence"},
+		{ID: "2", Title: "TitleB", Content: "be not afraid of greatness\nsilence is golden"},
+		{ID: "3", Title: "TitleC", Content: "all the world’s a stage"},
+	}
+	searcher := newTestMemoryStore(data)
+
+	testCases := []struct {
+		name     string
+		options  SearchOptions
+		expected []string
+	}{
+		{
+			name:     "query",
+			options:  SearchOptions{Query: "silence"},
+			expected: []string{"the rest is <mark>silence</mark>", "<mark>silence</mark> is golden"},
+		},
+		{
+			name:     "fuzziness",
+			options:  SearchOptions{Query: "silense", Fuzziness: 1},
+			expected: []string{"the rest is <mark>silence</mark>", "<mark>silence</mark> is golden"},
+		},
+		{
+			name:     "work id",
+			options:  SearchOptions{Query: "silence", WorkID: "2"},
+			expected: []string{"<mark>silence</mark> is golden"},
+		},
+		{
+			name:     "line number filter",
+			options:  SearchOptions{Query: "be", Filters: Filters{LineNumber: &Range{From: 2}}},
+			expected: nil,
+		},
+		{
+			name:     "phrase",
+			options:  SearchOptions{Structured: &QueryClause{Phrase: &PhraseClause{Text: "rest is silence"}}},
+			expected: []string{"the <mark>rest</mark> <mark>is</mark> <mark>silence</mark>"},
+		},
+		{
+			name:     "prefix",
+			options:  SearchOptions{Structured: &QueryClause{Prefix: &PrefixClause{Prefix: "Gold"}}},
+			expected: []string{"silence is <mark>golden</mark>"},
+		},
+		{
+			name: "bool",
+			options: SearchOptions{Structured: &QueryClause{Bool: &BoolClause{
+				Must:    []QueryClause{{Match: &MatchClause{Text: "not be", Operator: "and"}}},
+				MustNot: []QueryClause{{Match: &MatchClause{Text: "afraid"}}},
+			}}},
+			expected: []string{"to <mark>be</mark> or <mark>not</mark> to <mark>be</mark>"},
+		},
+		{
+			name:     "apostrophe",
+			options:  SearchOptions{Query: "world’s"},
+			expected: []string{"all the <mark>world’s</mark> a stage"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			tC.options.PageNumber = 1
+			tC.options.PageSize = 10
+			tC.options.SortBy = []string{"Title", "LineNumber"}
+			result, err := searcher.Search(tC.options)
+			assert.Nil(t, err)
+			var lines []string
+			for _, hit := range result.Data {
+				lines = append(lines, hit.Line)
+			}
+			assert.Equal(t, tC.expected, lines)
+			assert.Equal(t, len(tC.expected), result.Meta.TotalResults)
+		})
+	}
+}
+
+func TestMemoryStore_Search_Score(t *testing.T) {
+	searcher := newTestMemoryStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "love is blind and lovers cannot see\nlove love love"},
+	})
+
+	result, err := searcher.Search(SearchOptions{Query: "love", PageNumber: 2, PageSize: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.Meta.TotalResults)
+	if assert.Len(t, result.Data, 1) {
+		assert.Equal(t, 1, result.Data[0].LineNumber)
+	}
+}
+
+func TestMemoryStore_Search_Facets(t *testing.T) {
+	searcher := newTestMemoryStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "love\nlove"},
+		{ID: "2", Title: "TitleB", Content: "love\nhate"},
+	})
+
+	result, err := searcher.Search(SearchOptions{
+		Query:      "love",
+		PageNumber: 1,
+		PageSize:   10,
+		Facets:     map[string]int{"workId": 10, "speaker": 10},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]FacetCount{
+		"speaker": {},
+		"workId":  {{Count: 2, Term: "1"}, {Count: 1, Term: "2"}},
+	}, result.Meta.Facets)
+
+	_, err = searcher.Search(SearchOptions{PageNumber: 1, PageSize: 10, Facets: map[string]int{"Text": 10}})
+	assert.True(t, errors.Is(err, ErrInvalidSearchOptions))
+}
+
+func TestMemoryStore_Search_Suggestions(t *testing.T) {
+	searcher := newTestMemoryStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "the quality of mercy"},
+	})
+
+	result, err := searcher.Search(SearchOptions{Query: "mercie", PageNumber: 1, PageSize: 10, Autocorrect: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []Suggestion{{Term: "mercie", Suggestion: "mercy"}}, result.Meta.Suggestions)
+	assert.Equal(t, "mercy", result.Meta.CorrectedQuery)
+	assert.Equal(t, 1, result.Meta.TotalResults)
+}
+
+func TestMemoryStore_BatchIndex_Concurrent(t *testing.T) {
+	s := NewMemoryStore()
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: strings.Repeat("the quality of mercy\n", memoryBatchSize*2+1)},
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.BatchIndex(data)
+	}()
+	// searches run between batches while the work is indexed
+	for indexing := true; indexing; {
+		select {
+		case err := <-done:
+			assert.Nil(t, err)
+			indexing = false
+		default:
+			_, err := s.Search(SearchOptions{Query: "mercy", PageNumber: 1, PageSize: 10})
+			assert.Nil(t, err)
+		}
+	}
+
+	result, err := s.Search(SearchOptions{Query: "mercy", PageNumber: 1, PageSize: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, memoryBatchSize*2+1, result.Meta.TotalResults)
+}
+
+func TestMemoryStore_Concordance(t *testing.T) {
+	searcher := newTestMemoryStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "the quality of mercy is not strain'd\nit droppeth as the gentle rain"},
+	})
+
+	concordance, err := searcher.Concordance(ConcordanceOptions{Term: "the", Width: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []ConcordanceLine{
+		{Keyword: "the", Left: "", LineNumber: 1, Right: " quality o", Title: "TitleA", WorkID: "1"},
+		{Keyword: "the", Left: "oppeth as ", LineNumber: 2, Right: " gentle ra", Title: "TitleA", WorkID: "1"}},
+		concordance.Data)
+}
+
+// TestStores_SameHits checks that both backends find the same lines for queries that
+// do not depend on stemming or stop words
+func TestStores_SameHits(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "the quality of mercy is not strained\nit droppeth as the gentle rain from heaven"},
+		{ID: "2", Title: "TitleB", Content: "upon the place beneath\nit is twice blest\n\nit blesseth him that gives and him that takes"},
+		{ID: "3", Title: "TitleC", Content: "mercy and rain", Edition: "folio"},
+	}
+	stores := newTestStores(data)
+
+	testCases := []SearchOptions{
+		{Query: "mercy rain"},
+		{Query: "mercy", Edition: DefaultEdition},
+		{Query: "heaven", Fuzziness: 1},
+		{Structured: &QueryClause{Phrase: &PhraseClause{Text: "gentle rain"}}},
+		{Structured: &QueryClause{Prefix: &PrefixClause{Prefix: "bless"}}},
+		{Filters: Filters{WorkIDs: []string{"2"}, LineNumber: &Range{To: 2}}},
+	}
+	for _, options := range testCases {
+		hits := make(map[string][]string)
+		for name, s := range stores {
+			err := s.Scan(options, func(hit Hit) error {
+				hits[name] = append(hits[name], hit.WorkID+":"+strconv.Itoa(hit.LineNumber))
+				return nil
+			})
+			assert.Nil(t, err)
+			sort.Strings(hits[name])
+		}
+		assert.NotEmpty(t, hits["bleve"], "%+v", options)
+		assert.Equal(t, hits["bleve"], hits["memory"], "%+v", options)
+	}
+}
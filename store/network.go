@@ -0,0 +1,110 @@
+package store
+
+import "sort"
+
+// NetworkNode is a character speaking in a play
+type NetworkNode struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Lines int    `json:"lines"` // lines spoken
+}
+
+// NetworkEdge links two characters speaking in the same scenes or one after the other
+type NetworkEdge struct {
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	Scenes    int    `json:"scenes"`    // scenes both characters speak in
+	Exchanges int    `json:"exchanges"` // speeches of one following a speech of the other
+	Weight    int    `json:"weight"`    // number of exchanges
+}
+
+// Network represents the characters of a play linked by the scenes they share and the
+// speeches they exchange
+type Network struct {
+	WorkID  string        `json:"workId"`
+	Edition string        `json:"edition"`
+	Title   string        `json:"title"`
+	Nodes   []NetworkNode `json:"nodes"`
+	Edges   []NetworkEdge `json:"edges"`
+}
+
+// characterPair is a pair of indexes of characters, the lowest first
+type characterPair [2]int
+
+func newCharacterPair(a, b int) characterPair {
+	if a > b {
+		a, b = b, a
+	}
+	return characterPair{a, b}
+}
+
+// network returns the network of the characters speaking in the play. Speeches in a
+// row in a scene are an exchange between their characters.
+func (c *cast) network() Network {
+	scenes := make(map[characterPair]int)
+	exchanges := make(map[characterPair]int)
+	var present []int // characters speaking in the current scene
+	countScene := func() {
+		for i, a := range present {
+			for _, b := range present[i+1:] {
+				scenes[newCharacterPair(a, b)]++
+			}
+		}
+	}
+	for i, s := range c.speeches {
+		if i > 0 && c.speeches[i-1].sceneID == s.sceneID {
+			exchanges[newCharacterPair(c.speeches[i-1].character, s.character)]++
+		} else {
+			countScene()
+			present = nil
+		}
+		if !containsInt(present, s.character) {
+			present = append(present, s.character)
+		}
+	}
+	countScene()
+
+	network := Network{
+		WorkID:  c.work.ID,
+		Edition: c.work.Edition,
+		Title:   c.work.Title,
+		Nodes:   []NetworkNode{},
+		Edges:   []NetworkEdge{},
+	}
+	for _, character := range c.characters {
+		if character.Lines > 0 {
+			network.Nodes = append(network.Nodes, NetworkNode{ID: character.ID, Name: character.Name, Lines: character.Lines})
+		}
+	}
+	pairs := make([]characterPair, 0, len(scenes))
+	for pair := range scenes {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	// characters exchanging speeches always share the scene of the exchange
+	for _, pair := range pairs {
+		network.Edges = append(network.Edges, NetworkEdge{
+			Source:    c.characters[pair[0]].ID,
+			Target:    c.characters[pair[1]].ID,
+			Scenes:    scenes[pair],
+			Exchanges: exchanges[pair],
+			Weight:    exchanges[pair],
+		})
+	}
+	return network
+}
+
+// Network returns the network of the characters of a play in an edition, or in the
+// edition of GetWorkByID if edition is empty
+func (c *corpus) Network(workID string, edition string) (Network, error) {
+	found, err := c.cast(workID, edition)
+	if err != nil {
+		return Network{}, err
+	}
+	return found.network(), nil
+}
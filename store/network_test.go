@@ -0,0 +1,38 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorpus_Network(t *testing.T) {
+	s := newTestMemoryStore([]ShakespeareWork{{ID: "HAMLET", Title: "Hamlet", Content: testCharactersPlay}})
+
+	network, err := s.Network("HAMLET", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "HAMLET", network.WorkID)
+	assert.Equal(t, DefaultEdition, network.Edition)
+	assert.Equal(t, []NetworkNode{
+		{ID: "HAMLET.hamlet", Name: "Hamlet", Lines: 4},
+		{ID: "HAMLET.horatio", Name: "Horatio", Lines: 1},
+		{ID: "HAMLET.francisco", Name: "Francisco", Lines: 1},
+	}, network.Nodes, "characters without lines are left out")
+	assert.Equal(t, []NetworkEdge{
+		{Source: "HAMLET.hamlet", Target: "HAMLET.horatio", Scenes: 1, Exchanges: 1, Weight: 1},
+		{Source: "HAMLET.hamlet", Target: "HAMLET.francisco", Scenes: 1, Exchanges: 1, Weight: 1},
+		{Source: "HAMLET.horatio", Target: "HAMLET.francisco", Scenes: 1},
+	}, network.Edges)
+
+	_, err = s.Network("MACBETH", "")
+	assert.True(t, errors.Is(err, ErrWorkNotFound))
+}
+
+func TestCast_Network_Exchanges(t *testing.T) {
+	content := "ACT I\n\nSCENE I. A room.\n\nROMEO.\nOne\n\nJULIET.\nTwo\n\n [_Aside._]\n\nJULIET.\nThree\n\nROMEO.\nFour\n\nSCENE II. Another room.\n\nJULIET.\nFive\n\nROMEO.\nSix"
+	network := readCast(ShakespeareWork{ID: "W", Content: content}, tokenizeWords).network()
+	assert.Equal(t, []NetworkEdge{
+		{Source: "W.romeo", Target: "W.juliet", Scenes: 2, Exchanges: 3, Weight: 3},
+	}, network.Edges)
+}
@@ -0,0 +1,484 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ModeWords matches the words of the query against the indexed words of lines
+	ModeWords = "words"
+	// ModeSubstring matches the query anywhere in the text of lines, ignoring case
+	ModeSubstring = "substring"
+	// ModeRegex matches the query as a regular expression (RE2 syntax) against the text of lines
+	ModeRegex = "regex"
+)
+
+const (
+	// gramSize is the length in bytes of the n-grams of the text of lines
+	gramSize = 3
+	// textSearchTimeout is the maximum duration of a substring or regex search
+	textSearchTimeout = 2 * time.Second
+	// timeoutCheckInterval is the number of lines read between checks of the timeout
+	timeoutCheckInterval = 256
+	// maxTextMatches is the maximum number of lines a substring or regex search returns
+	maxTextMatches = 10000
+)
+
+var (
+	// ErrSearchLimit is returned when a search would take too long
+	ErrSearchLimit = errors.New("search limit exceeded")
+)
+
+// trigramIndex maps the trigrams of the lowercased text of lines to the lines containing
+// them, so that substring and regex searches only read the lines that may match
+type trigramIndex struct {
+	mu       sync.RWMutex
+	postings map[string][]int // numbers of the lines containing each trigram, in order
+	count    int              // number of the last line added
+}
+
+func newTrigramIndex() *trigramIndex {
+	return &trigramIndex{postings: make(map[string][]int)}
+}
+
+// trigrams returns the distinct trigrams of the lowercased text
+func trigrams(text string) []string {
+	text = strings.ToLower(text)
+	seen := make(map[string]bool)
+	var grams []string
+	for i := 0; i+gramSize <= len(text); i++ {
+		gram := text[i : i+gramSize]
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
+
+// add indexes the text of a line. Lines must be added in increasing number.
+func (t *trigramIndex) add(n int, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, gram := range trigrams(text) {
+		t.postings[gram] = append(t.postings[gram], n)
+	}
+	t.count = n
+}
+
+// remove removes the line numbered n with the text from the postings of its trigrams,
+// leaving the postings returned by earlier lookups unchanged
+func (t *trigramIndex) remove(n int, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, gram := range trigrams(text) {
+		lines := t.postings[gram]
+		i := sort.SearchInts(lines, n)
+		if i == len(lines) || lines[i] != n {
+			continue
+		}
+		if len(lines) == 1 {
+			delete(t.postings, gram)
+			continue
+		}
+		// a new slice is built as candidates returned by lookups may still be read
+		kept := make([]int, 0, len(lines)-1)
+		kept = append(kept, lines[:i]...)
+		t.postings[gram] = append(kept, lines[i+1:]...)
+	}
+}
+
+// gramQuery is a condition on the trigrams of the lines matching a pattern. A nil
+// gramQuery is met by every line.
+type gramQuery struct {
+	any   bool     // lines must meet any of subs instead of all of grams and subs
+	grams []string // trigrams of the lowercased text
+	subs  []*gramQuery
+}
+
+// allOf returns the query met by lines meeting every query
+func allOf(queries ...*gramQuery) *gramQuery {
+	q := &gramQuery{}
+	for _, sub := range queries {
+		if sub != nil {
+			q.subs = append(q.subs, sub)
+		}
+	}
+	if len(q.subs) == 0 {
+		return nil
+	}
+	return q
+}
+
+// anyOf returns the query met by lines containing any of the strings
+func anyOf(texts []string) *gramQuery {
+	q := &gramQuery{any: true}
+	for _, text := range texts {
+		grams := trigrams(text)
+		if len(grams) == 0 {
+			return nil // a string too short to have trigrams may be in any line
+		}
+		q.subs = append(q.subs, &gramQuery{grams: grams})
+	}
+	if len(q.subs) == 1 {
+		return q.subs[0]
+	}
+	return q
+}
+
+// maxExactStrings is the maximum number of strings a part of a pattern can match
+// to be looked up as those strings
+const maxExactStrings = 16
+
+// exactStrings returns the strings a regular expression matches, if there are at
+// most maxExactStrings of them
+func exactStrings(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText,
+		syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		var texts []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(texts) == maxExactStrings {
+					return nil, false
+				}
+				texts = append(texts, string(r))
+			}
+		}
+		return texts, true
+	case syntax.OpCapture:
+		return exactStrings(re.Sub[0])
+	case syntax.OpQuest:
+		texts, ok := exactStrings(re.Sub[0])
+		return append(texts, ""), ok && len(texts) < maxExactStrings
+	case syntax.OpConcat:
+		texts := []string{""}
+		for _, sub := range re.Sub {
+			subTexts, ok := exactStrings(sub)
+			if !ok || len(texts)*len(subTexts) > maxExactStrings {
+				return nil, false
+			}
+			texts = product(texts, subTexts)
+		}
+		return texts, true
+	case syntax.OpAlternate:
+		var texts []string
+		for _, sub := range re.Sub {
+			subTexts, ok := exactStrings(sub)
+			if !ok || len(texts)+len(subTexts) > maxExactStrings {
+				return nil, false
+			}
+			texts = append(texts, subTexts...)
+		}
+		return texts, true
+	}
+	return nil, false
+}
+
+// product returns the concatenations of every string of a with every string of b
+func product(a, b []string) []string {
+	texts := make([]string, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			texts = append(texts, x+y)
+		}
+	}
+	return texts
+}
+
+// regexGrams returns the trigrams the lines matching a regular expression contain.
+// Parts of the pattern matching few strings are looked up as those strings, and
+// consecutive ones are joined so that their trigrams can span them.
+func regexGrams(re *syntax.Regexp) *gramQuery {
+	if texts, ok := exactStrings(re); ok {
+		return anyOf(texts)
+	}
+	switch re.Op {
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexGrams(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return regexGrams(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var queries []*gramQuery
+		run := []string{""} // strings matched by the current run of parts
+		for _, sub := range re.Sub {
+			texts, ok := exactStrings(sub)
+			if ok && len(run)*len(texts) <= maxExactStrings {
+				run = product(run, texts)
+				continue
+			}
+			queries = append(queries, anyOf(run))
+			run = []string{""}
+			if ok {
+				run = texts
+			} else {
+				queries = append(queries, regexGrams(sub))
+			}
+		}
+		return allOf(append(queries, anyOf(run))...)
+	case syntax.OpAlternate:
+		q := &gramQuery{any: true}
+		for _, sub := range re.Sub {
+			subQuery := regexGrams(sub)
+			if subQuery == nil {
+				return nil
+			}
+			q.subs = append(q.subs, subQuery)
+		}
+		return q
+	}
+	return nil
+}
+
+// intersectLines returns the numbers in both sorted lists
+func intersectLines(a, b []int) []int {
+	var result []int
+	j := 0
+	for _, n := range a {
+		for j < len(b) && b[j] < n {
+			j++
+		}
+		if j < len(b) && b[j] == n {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// unionLines returns the numbers in either sorted list
+func unionLines(a, b []int) []int {
+	result := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			result = append(result, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			result = append(result, b[j])
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// lookup returns the sorted numbers of the lines meeting a query, or nil and false if
+// every line does
+func (t *trigramIndex) lookup(q *gramQuery) ([]int, bool) {
+	if q == nil {
+		return nil, false
+	}
+	if q.any {
+		var result []int
+		for _, sub := range q.subs {
+			lines, ok := t.lookup(sub)
+			if !ok {
+				return nil, false
+			}
+			result = unionLines(result, lines)
+		}
+		return result, true
+	}
+
+	var lists [][]int
+	for _, gram := range q.grams {
+		lists = append(lists, t.postings[gram])
+	}
+	for _, sub := range q.subs {
+		if lines, ok := t.lookup(sub); ok {
+			lists = append(lists, lines)
+		}
+	}
+	if len(lists) == 0 {
+		return nil, false
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return len(lists[i]) < len(lists[j])
+	})
+	result := lists[0]
+	for _, list := range lists[1:] {
+		result = intersectLines(result, list)
+	}
+	return result, true
+}
+
+// candidates returns the numbers of the lines meeting a query, in order
+func (t *trigramIndex) candidates(q *gramQuery) []int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if lines, ok := t.lookup(q); ok {
+		return lines
+	}
+	all := make([]int, t.count)
+	for i := range all {
+		all[i] = i + 1
+	}
+	return all
+}
+
+// textPattern compiles the pattern of a substring or regex search and returns the
+// trigrams of the lines it may match
+func textPattern(options SearchOptions) (*regexp.Regexp, *gramQuery, error) {
+	mode := options.Mode
+	text := options.Query
+	if options.Regex != "" {
+		if options.Query != "" {
+			return nil, nil, invalidOptions("q and regex cannot be used together")
+		}
+		if mode == ModeSubstring {
+			return nil, nil, invalidOptions("regex cannot be used in %s mode", mode)
+		}
+		mode = ModeRegex
+		text = options.Regex
+	}
+	if text == "" {
+		return nil, nil, invalidOptions("%s search requires q", mode)
+	}
+	if options.Structured != nil {
+		return nil, nil, invalidOptions("%s search does not support structured queries", mode)
+	}
+	if options.Fuzziness != 0 {
+		return nil, nil, invalidOptions("%s search does not support fuzziness", mode)
+	}
+	if mode == ModeSubstring {
+		return regexp.MustCompile("(?i)" + regexp.QuoteMeta(text)), anyOf([]string{text}), nil
+	}
+	pattern, err := regexp.Compile(text)
+	if err != nil {
+		return nil, nil, invalidOptions("invalid regex: %s", err)
+	}
+	parsed, err := syntax.Parse(text, syntax.Perl)
+	if err != nil {
+		return nil, nil, invalidOptions("invalid regex: %s", err)
+	}
+	return pattern, regexGrams(parsed.Simplify()), nil
+}
+
+// textMatch is a line matching a substring or regex search with the spans of the matches
+type textMatch struct {
+	scoredLine
+	spans [][]int
+}
+
+// findText returns the lines matching a substring or regex search in their sort order.
+// Lines are looked up in the trigram index, then matched against the text of the line.
+// The score of a line is its number of matches. The search stops at the first
+// maxTextMatches lines matching in index order, which are sorted and reported as
+// truncated. An error wrapping ErrSearchLimit is returned if the search takes longer
+// than textSearchTimeout.
+func (c *corpus) findText(options SearchOptions) ([]textMatch, bool, error) {
+	pattern, grams, err := textPattern(options)
+	if err != nil {
+		return nil, false, err
+	}
+	deadline := time.Now().Add(textSearchTimeout)
+	var found []textMatch
+	truncated := false
+	for i, n := range c.grams.candidates(grams) {
+		if i%timeoutCheckInterval == 0 && time.Now().After(deadline) {
+			return nil, false, fmt.Errorf("%w: search took longer than %s, try a more specific pattern", ErrSearchLimit, textSearchTimeout)
+		}
+		id := strconv.Itoa(n)
+		value, ok := c.lines.Load(id)
+		if !ok {
+			continue
+		}
+		doc := value.(Document)
+		if !filter(options, doc) {
+			continue
+		}
+		matches := pattern.FindAllStringIndex(doc.line(), -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var spans [][]int // empty matches, e.g. of ^, are not highlighted
+		for _, span := range matches {
+			if span[1] > span[0] {
+				spans = append(spans, span)
+			}
+		}
+		if len(found) == maxTextMatches {
+			truncated = true
+			break
+		}
+		found = append(found, textMatch{
+			scoredLine: scoredLine{id: id, doc: doc, score: float64(len(matches))},
+			spans:      spans,
+		})
+	}
+	less := lessLines(options.SortBySlice())
+	sort.Slice(found, func(i, j int) bool {
+		return less(found[i].scoredLine, found[j].scoredLine)
+	})
+	return found, truncated, nil
+}
+
+// isTextSearch reports whether the options are for a substring or regex search, which
+// stores run on their corpus instead of their index
+func isTextSearch(options SearchOptions) bool {
+	return options.Mode == ModeSubstring || options.Mode == ModeRegex || options.Regex != ""
+}
+
+// validateMode returns an error if the mode of the options is unknown
+func validateMode(options SearchOptions) error {
+	switch options.Mode {
+	case "", ModeWords, ModeSubstring, ModeRegex:
+		return nil
+	}
+	return invalidOptions("invalid mode: %s", options.Mode)
+}
+
+// searchText runs a substring or regex search
+func (c *corpus) searchText(options SearchOptions) (SearchResult, error) {
+	searchResult := newSearchResult(options)
+	found, truncated, err := c.findText(options)
+	if err != nil {
+		return searchResult, err
+	}
+	lines := make([]scoredLine, len(found))
+	for i, match := range found {
+		lines[i] = match.scoredLine
+	}
+	if searchResult.Meta.Facets, err = countFacets(lines, options.Facets); err != nil {
+		return searchResult, err
+	}
+	searchResult.Meta.TotalResults = len(found)
+	searchResult.Meta.Truncated = truncated
+	for i := options.Offset(); i < len(found) && i < options.Offset()+options.PageSize; i++ {
+		searchResult.Data = append(searchResult.Data, newHit(found[i].scoredLine, found[i].spans))
+	}
+	return searchResult, nil
+}
+
+// scanText calls fn for every hit of a substring or regex search in their sort order,
+// up to maxTextMatches hits
+func (c *corpus) scanText(options SearchOptions, fn func(Hit) error) error {
+	found, _, err := c.findText(options)
+	if err != nil {
+		return err
+	}
+	for _, match := range found {
+		if err := fn(newHit(match.scoredLine, match.spans)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"errors"
+	"regexp/syntax"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexGrams(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected *gramQuery
+	}{
+		{pattern: "mercy", expected: &gramQuery{grams: []string{"mer", "erc", "rcy"}}},
+		{pattern: "(?i)Tis", expected: &gramQuery{grams: []string{"tis"}}},
+		{
+			pattern: `\bthou\s+\w+est\b`,
+			expected: &gramQuery{subs: []*gramQuery{
+				{grams: []string{"tho", "hou"}},
+				{grams: []string{"est"}},
+			}},
+		},
+		{
+			pattern: "thou|thee",
+			expected: &gramQuery{any: true, subs: []*gramQuery{
+				{grams: []string{"tho", "hou"}},
+				{grams: []string{"the", "hee"}},
+			}},
+		},
+		{
+			pattern: "(love|lord)s?",
+			expected: &gramQuery{any: true, subs: []*gramQuery{
+				{grams: []string{"lov", "ove", "ves"}},
+				{grams: []string{"lov", "ove"}},
+				{grams: []string{"lor", "ord", "rds"}},
+				{grams: []string{"lor", "ord"}},
+			}},
+		},
+		{
+			pattern: "lo?ve",
+			expected: &gramQuery{any: true, subs: []*gramQuery{
+				{grams: []string{"lov", "ove"}},
+				{grams: []string{"lve"}},
+			}},
+		},
+		{pattern: "lo(ve|rd)+", expected: nil},
+		{pattern: "love|.*", expected: nil},
+		{pattern: `\w+`, expected: nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.pattern, func(t *testing.T) {
+			re, err := syntax.Parse(tC.pattern, syntax.Perl)
+			assert.Nil(t, err)
+			assert.Equal(t, tC.expected, regexGrams(re.Simplify()))
+		})
+	}
+}
+
+func TestTrigramIndex_Candidates(t *testing.T) {
+	index := newTrigramIndex()
+	index.add(1, "The quality of mercy")
+	index.add(2, "It droppeth as the gentle rain")
+	index.add(3, "'Tis mightiest in the mightiest")
+
+	testCases := []struct {
+		name     string
+		query    *gramQuery
+		expected []int
+	}{
+		{name: "all lines", query: nil, expected: []int{1, 2, 3}},
+		{name: "case", query: anyOf([]string{"THE"}), expected: []int{1, 2, 3}},
+		{name: "all of", query: allOf(anyOf([]string{"tis"}), anyOf([]string{"might"})), expected: []int{3}},
+		{name: "none", query: allOf(anyOf([]string{"mercy"}), anyOf([]string{"rain"})), expected: nil},
+		{name: "any of", query: anyOf([]string{"mercy", "rain"}), expected: []int{1, 2}},
+		{name: "missing trigram", query: anyOf([]string{"blood"}), expected: nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, index.candidates(tC.query))
+		})
+	}
+}
+
+func TestTrigramIndex_Remove(t *testing.T) {
+	index := newTrigramIndex()
+	index.add(1, "The quality of mercy")
+	index.add(2, "The quality of mercy")
+	index.add(3, "The quality of mercy")
+
+	candidates := index.candidates(anyOf([]string{"rcy"}))
+	index.remove(1, "The quality of mercy")
+
+	assert.Equal(t, []int{1, 2, 3}, candidates)
+	assert.Equal(t, []int{2, 3}, index.candidates(anyOf([]string{"rcy"})))
+}
+
+func TestStores_TextSearch(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "'Tis mightiest in the mightiest\nthou hast it\nthou knowest not"},
+		{ID: "2", Title: "TitleB", Content: "And deep-brain’d sonnets that did amplify\nthe brain is sick"},
+	}
+	testCases := []struct {
+		name     string
+		options  SearchOptions
+		expected []string
+	}{
+		{
+			name:     "substring in word",
+			options:  SearchOptions{Query: "tis", Mode: ModeSubstring},
+			expected: []string{"'<mark>Tis</mark> mightiest in the mightiest"},
+		},
+		{
+			name:     "substring with apostrophe",
+			options:  SearchOptions{Query: "brain’d", Mode: ModeSubstring},
+			expected: []string{"And deep-<mark>brain’d</mark> sonnets that did amplify"},
+		},
+		{
+			name:     "short substring",
+			options:  SearchOptions{Query: "is", Mode: ModeSubstring, WorkID: "2"},
+			expected: []string{"the brain <mark>is</mark> sick"},
+		},
+		{
+			name:     "regex",
+			options:  SearchOptions{Query: `\bthou\s+\w+est\b`, Mode: ModeRegex},
+			expected: []string{"<mark>thou knowest</mark> not"},
+		},
+		{
+			name:     "regex option",
+			options:  SearchOptions{Regex: "thou (hast|knowest)"},
+			expected: []string{"<mark>thou hast</mark> it", "<mark>thou knowest</mark> not"},
+		},
+		{
+			name:     "regex without literals",
+			options:  SearchOptions{Query: `^\w+$`, Mode: ModeRegex},
+			expected: nil,
+		},
+		{
+			name:     "regex is case sensitive",
+			options:  SearchOptions{Query: "^the", Mode: ModeRegex},
+			expected: []string{"<mark>the</mark> brain is sick"},
+		},
+	}
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		for _, tC := range testCases {
+			t.Run(tC.name, func(t *testing.T) {
+				tC.options.PageNumber = 1
+				tC.options.PageSize = 10
+				tC.options.SortBy = []string{"Title", "LineNumber"}
+				result, err := s.Search(tC.options)
+				assert.Nil(t, err)
+				var lines []string
+				for _, hit := range result.Data {
+					lines = append(lines, hit.Line)
+				}
+				assert.Equal(t, tC.expected, lines)
+				assert.Equal(t, len(tC.expected), result.Meta.TotalResults)
+			})
+		}
+	})
+}
+
+func TestStores_TextSearch_Errors(t *testing.T) {
+	searcher := newTestMemoryStore(nil)
+	testCases := []struct {
+		name    string
+		options SearchOptions
+	}{
+		{name: "unknown mode", options: SearchOptions{Query: "tis", Mode: "fuzzy"}},
+		{name: "no query", options: SearchOptions{Mode: ModeSubstring}},
+		{name: "invalid regex", options: SearchOptions{Query: "thou(", Mode: ModeRegex}},
+		{name: "fuzziness", options: SearchOptions{Query: "tis", Mode: ModeSubstring, Fuzziness: 1}},
+		{name: "q and regex", options: SearchOptions{Query: "tis", Regex: "tis"}},
+		{name: "substring regex", options: SearchOptions{Mode: ModeSubstring, Regex: "tis"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := searcher.Search(tC.options)
+			assert.True(t, errors.Is(err, ErrInvalidSearchOptions), "%v", err)
+		})
+	}
+}
+
+func TestMemoryStore_TextSearch_Limit(t *testing.T) {
+	searcher := newTestMemoryStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: strings.Repeat("thou\n", maxTextMatches+1)},
+	})
+
+	result, err := searcher.Search(SearchOptions{Regex: "thou", PageNumber: 1, PageSize: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, maxTextMatches, result.Meta.TotalResults)
+	assert.True(t, result.Meta.Truncated)
+	assert.Len(t, result.Data, 10)
+
+	scanned := 0
+	err = searcher.Scan(SearchOptions{Regex: "thou"}, func(Hit) error {
+		scanned++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, maxTextMatches, scanned)
+
+	result, err = searcher.Search(SearchOptions{
+		Regex:      "thou",
+		PageNumber: 1,
+		PageSize:   10,
+		Filters:    Filters{LineNumber: &Range{To: maxTextMatches}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, maxTextMatches, result.Meta.TotalResults)
+	assert.False(t, result.Meta.Truncated)
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+// occurrenceBatchSize is the number of documents fetched from the index at a time
+const occurrenceBatchSize = 1000
+
+func newOccurrenceRequest(term string, workID string, from int) *bleve.SearchRequest {
+	matchQuery := bleve.NewMatchQuery(term)
+	matchQuery.SetField("Text")
+	var searchQuery query.Query = matchQuery
+	if workID != "" {
+		idQuery := bleve.NewTermQuery(workID)
+		idQuery.SetField("WorkID")
+		searchQuery = bleve.NewConjunctionQuery(searchQuery, idQuery)
+	}
+	req := bleve.NewSearchRequestOptions(searchQuery, occurrenceBatchSize, from, false)
+	req.SortBy([]string{"Title", "LineNumber"})
+	req.IncludeLocations = true
+	return req
+}
+
+// eachOccurrence calls fn in text order for every line matching the term along with
+// the locations of the term within the line sorted by their position
+func (b *BleveStore) eachOccurrence(term string, workID string, fn func(docID string, doc Document, occurrences []token) error) error {
+	for from, total := 0, 1; from < total; from += occurrenceBatchSize {
+		result, err := b.index.Search(newOccurrenceRequest(term, workID, from))
+		if err != nil {
+			return err
+		}
+		total = int(result.Total)
+		for _, hit := range result.Hits {
+			found, ok := b.lines.Load(hit.ID)
+			if !ok {
+				return fmt.Errorf("line not found: %s", hit.ID)
+			}
+			doc, ok := found.(Document)
+			if !ok {
+				return fmt.Errorf("Failed to parse a line: %s", hit.ID)
+			}
+
+			var occurrences []token
+			for term, locations := range hit.Locations["Text"] {
+				for _, loc := range locations {
+					occurrences = append(occurrences, token{term: term, start: int(loc.Start), end: int(loc.End), position: int(loc.Pos)})
+				}
+			}
+			sort.Slice(occurrences, func(i, j int) bool {
+				return occurrences[i].start < occurrences[j].start
+			})
+			if err := fn(hit.ID, doc, occurrences); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+const (
+	// maxFacetSize is the maximum number of terms returned for a facet
+	maxFacetSize = 100
+	// maxFuzziness is the maximum edit distance supported by the index
+	maxFuzziness = 2
+)
+
+var (
+	// ErrInvalidSearchOptions is returned when the search options cannot be turned into a query
+	ErrInvalidSearchOptions = errors.New("invalid search options")
+)
+
+// facetFields maps the facet names users can request to the indexed fields
+var facetFields = map[string]string{
+	"edition": "Edition",
+	"form":    "Form",
+	"meter":   "Meter",
+	"speaker": "Speaker",
+	"title":   "Title",
+	"workId":  "WorkID",
+}
+
+// QueryClause represents a structured query on the text of lines.
+// Exactly one of its fields must be set.
+type QueryClause struct {
+	Bool   *BoolClause   `json:"bool,omitempty"`
+	Match  *MatchClause  `json:"match,omitempty"`
+	Phrase *PhraseClause `json:"phrase,omitempty"`
+	Prefix *PrefixClause `json:"prefix,omitempty"`
+}
+
+// BoolClause combines queries. A line matches when it matches all of Must and none of MustNot.
+// Should queries raise the score and at least one of them must match if there is no Must query.
+type BoolClause struct {
+	Must    []QueryClause `json:"must,omitempty"`
+	MustNot []QueryClause `json:"mustNot,omitempty"`
+	Should  []QueryClause `json:"should,omitempty"`
+}
+
+// MatchClause matches lines containing any (or all with operator "and") of the terms
+type MatchClause struct {
+	Fuzziness int    `json:"fuzziness"`
+	Operator  string `json:"operator"`
+	Text      string `json:"text"`
+}
+
+// PhraseClause matches lines containing the terms in the same order
+type PhraseClause struct {
+	Text string `json:"text"`
+}
+
+// PrefixClause matches lines containing a word starting with the prefix
+type PrefixClause struct {
+	Prefix string `json:"prefix"`
+}
+
+// Filters represents conditions lines must meet without affecting the score
+type Filters struct {
+	LineNumber *Range   `json:"lineNumber,omitempty"`
+	WorkIDs    []string `json:"workId,omitempty"`
+}
+
+// Range represents an inclusive range of numbers. A zero bound is unbounded.
+type Range struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// FacetCount represents the number of matching lines for a facet term
+type FacetCount struct {
+	Count int    `json:"count"`
+	Term  string `json:"term"`
+}
+
+func invalidOptions(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSearchOptions, fmt.Sprintf(format, a...))
+}
+
+// toQueries returns the queries of clauses searching the words of field
+func toQueries(clauses []QueryClause, field string) ([]query.Query, error) {
+	queries := make([]query.Query, 0, len(clauses))
+	for _, clause := range clauses {
+		q, err := clause.toQuery(field)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func (b *BoolClause) toQuery(field string) (query.Query, error) {
+	if len(b.Must)+len(b.Should)+len(b.MustNot) == 0 {
+		return nil, invalidOptions("bool query requires at least one clause")
+	}
+	must, err := toQueries(b.Must, field)
+	if err != nil {
+		return nil, err
+	}
+	should, err := toQueries(b.Should, field)
+	if err != nil {
+		return nil, err
+	}
+	mustNot, err := toQueries(b.MustNot, field)
+	if err != nil {
+		return nil, err
+	}
+	if len(must)+len(should) == 0 {
+		must = append(must, bleve.NewMatchAllQuery())
+	}
+	return query.NewBooleanQuery(must, should, mustNot), nil
+}
+
+func (m *MatchClause) toQuery(field string) (query.Query, error) {
+	if m.Text == "" {
+		return nil, invalidOptions("match query requires text")
+	}
+	if m.Fuzziness < 0 || m.Fuzziness > maxFuzziness {
+		return nil, invalidOptions("fuzziness must be between 0 and %d", maxFuzziness)
+	}
+	matchQuery := bleve.NewMatchQuery(m.Text)
+	matchQuery.SetField(field)
+	matchQuery.SetFuzziness(m.Fuzziness)
+	switch m.Operator {
+	case "", "or":
+		matchQuery.SetOperator(query.MatchQueryOperatorOr)
+	case "and":
+		matchQuery.SetOperator(query.MatchQueryOperatorAnd)
+	default:
+		return nil, invalidOptions("invalid match operator: %s", m.Operator)
+	}
+	return matchQuery, nil
+}
+
+// toQuery returns the query of a clause searching the words of field, Text or StageDirection
+func (q *QueryClause) toQuery(field string) (query.Query, error) {
+	var queries []query.Query
+	if q.Bool != nil {
+		boolQuery, err := q.Bool.toQuery(field)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, boolQuery)
+	}
+	if q.Match != nil {
+		matchQuery, err := q.Match.toQuery(field)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, matchQuery)
+	}
+	if q.Phrase != nil {
+		if q.Phrase.Text == "" {
+			return nil, invalidOptions("phrase query requires text")
+		}
+		phraseQuery := bleve.NewMatchPhraseQuery(q.Phrase.Text)
+		phraseQuery.SetField(field)
+		queries = append(queries, phraseQuery)
+	}
+	if q.Prefix != nil {
+		if q.Prefix.Prefix == "" {
+			return nil, invalidOptions("prefix query requires a prefix")
+		}
+		prefixQuery := bleve.NewPrefixQuery(strings.ToLower(q.Prefix.Prefix))
+		if field == "Text" { // unstemmed words
+			prefixQuery.SetField("Words")
+		} else {
+			prefixQuery.SetField(field)
+		}
+		queries = append(queries, prefixQuery)
+	}
+	if len(queries) != 1 {
+		return nil, invalidOptions("a query clause must have exactly one of bool, match, phrase or prefix")
+	}
+	return queries[0], nil
+}
+
+// termFilter returns a query matching the lines with the term in the field, which adds
+// nothing to their score
+func termFilter(field string, term string) query.Query {
+	termQuery := bleve.NewTermQuery(term)
+	termQuery.SetField(field)
+	termQuery.SetBoost(0)
+	return termQuery
+}
+
+// notFilter returns a query matching the lines not matching the filter, which adds
+// nothing to their score
+func notFilter(filter query.Query) query.Query {
+	all := bleve.NewMatchAllQuery()
+	all.SetBoost(0)
+	return query.NewBooleanQuery([]query.Query{all}, nil, []query.Query{filter})
+}
+
+// toQueries returns the queries of the filters, which add nothing to the score of lines
+func (f *Filters) toQueries() []query.Query {
+	var queries []query.Query
+	if len(f.WorkIDs) > 0 {
+		var ids []query.Query
+		for _, id := range f.WorkIDs {
+			ids = append(ids, termFilter("WorkID", id))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(ids...))
+	}
+	if f.LineNumber != nil {
+		var from, to string // empty bound is unbounded
+		if f.LineNumber.From > 0 {
+			from = toZeroPaddedString(f.LineNumber.From)
+		}
+		if f.LineNumber.To > 0 {
+			to = toZeroPaddedString(f.LineNumber.To)
+		}
+		inclusive := true
+		rangeQuery := bleve.NewTermRangeInclusiveQuery(from, to, &inclusive, &inclusive)
+		rangeQuery.SetField("LineNumber")
+		rangeQuery.SetBoost(0)
+		queries = append(queries, rangeQuery)
+	}
+	return queries
+}
+
+// facetField returns the field of a facet after checking the number of terms requested
+func facetField(name string, size int) (string, error) {
+	field, ok := facetFields[name]
+	if !ok {
+		return "", invalidOptions("invalid facet: %s", name)
+	}
+	if size < 1 || size > maxFacetSize {
+		return "", invalidOptions("facet size must be between 1 and %d", maxFacetSize)
+	}
+	return field, nil
+}
+
+func addFacets(req *bleve.SearchRequest, facets map[string]int) error {
+	for name, size := range facets {
+		field, err := facetField(name, size)
+		if err != nil {
+			return err
+		}
+		req.AddFacet(name, bleve.NewFacetRequest(field, size))
+	}
+	return nil
+}
+
+func parseFacets(results search.FacetResults) map[string][]FacetCount {
+	if len(results) == 0 {
+		return nil
+	}
+	facets := make(map[string][]FacetCount, len(results))
+	for name, result := range results {
+		counts := make([]FacetCount, 0, len(result.Terms))
+		for _, term := range result.Terms {
+			if term.Term == "" {
+				// lines without a value, e.g. outside of speeches for speaker
+				continue
+			}
+			counts = append(counts, FacetCount{Count: term.Count, Term: term.Term})
+		}
+		sort.SliceStable(counts, func(i, j int) bool {
+			return counts[i].Count > counts[j].Count
+		})
+		facets[name] = counts
+	}
+	return facets
+}
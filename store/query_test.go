@@ -0,0 +1,192 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBleveStore_Search_Structured(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "to be or not to be\nthe rest is silence"},
+		{ID: "2", Title: "TitleB", Content: "be not afraid of greatness\nsilence is golden"},
+		{ID: "3", Title: "TitleC", Content: "all the world’s a stage"},
+	}
+	searcher := newTestStore(data)
+
+	testCases := []struct {
+		name     string
+		options  SearchOptions
+		expected []string
+	}{
+		{
+			name:     "phrase",
+			options:  SearchOptions{Structured: &QueryClause{Phrase: &PhraseClause{Text: "rest is silence"}}},
+			expected: []string{"the rest is silence"},
+		},
+		{
+			name:     "match and",
+			options:  SearchOptions{Structured: &QueryClause{Match: &MatchClause{Text: "silence rest", Operator: "and"}}},
+			expected: []string{"the rest is silence"},
+		},
+		{
+			name:     "prefix",
+			options:  SearchOptions{Structured: &QueryClause{Prefix: &PrefixClause{Prefix: "Great"}}},
+			expected: []string{"be not afraid of greatness"},
+		},
+		{
+			name: "bool",
+			options: SearchOptions{Structured: &QueryClause{Bool: &BoolClause{
+				Should:  []QueryClause{{Match: &MatchClause{Text: "silence"}}, {Match: &MatchClause{Text: "stage"}}},
+				MustNot: []QueryClause{{Match: &MatchClause{Text: "golden"}}},
+			}}},
+			expected: []string{"the rest is silence", "all the world’s a stage"},
+		},
+		{
+			name: "query combined with structured query",
+			options: SearchOptions{
+				Query:      "silence",
+				Structured: &QueryClause{Bool: &BoolClause{MustNot: []QueryClause{{Match: &MatchClause{Text: "rest"}}}}},
+			},
+			expected: []string{"silence is golden"},
+		},
+		{
+			name:     "filter work ids",
+			options:  SearchOptions{Filters: Filters{WorkIDs: []string{"2", "3"}}},
+			expected: []string{"be not afraid of greatness", "silence is golden", "all the world’s a stage"},
+		},
+		{
+			name:     "filter line numbers",
+			options:  SearchOptions{Query: "silence be", Filters: Filters{LineNumber: &Range{From: 2}}},
+			expected: []string{"the rest is silence", "silence is golden"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.options.PageNumber = 1
+			tc.options.PageSize = 10
+			tc.options.SortBy = []string{"Title", "LineNumber"}
+			result, err := searcher.Search(tc.options)
+			assert.Nil(t, err)
+
+			var lines []string
+			for _, hit := range result.Data {
+				lines = append(lines, stripHighlight(hit.Line))
+			}
+			assert.Equal(t, tc.expected, lines)
+		})
+	}
+}
+
+func TestBleveStore_Search_Facets(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "love\nlove"},
+		{ID: "2", Title: "TitleB", Content: "love\nhate"},
+	}
+	searcher := newTestStore(data)
+
+	result, err := searcher.Search(SearchOptions{
+		Query:      "love",
+		PageNumber: 1,
+		PageSize:   10,
+		Facets:     map[string]int{"workId": 10},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]FacetCount{
+		"workId": {{Count: 2, Term: "1"}, {Count: 1, Term: "2"}},
+	}, result.Meta.Facets)
+}
+
+func TestBleveStore_Search_FiltersDoNotScore(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "love\nlove and hate"},
+		{ID: "2", Title: "TitleB", Content: "love\nhate"},
+	}
+	searcher := newTestStore(data)
+
+	options := SearchOptions{Query: "love", PageNumber: 1, PageSize: 10, SortBy: []string{"Title", "LineNumber"}}
+	unfiltered, err := searcher.Search(options)
+	assert.Nil(t, err)
+	options.WorkID = "1"
+	options.Edition = DefaultEdition
+	options.Filters = Filters{WorkIDs: []string{"1"}, LineNumber: &Range{To: 10}}
+	filtered, err := searcher.Search(options)
+	assert.Nil(t, err)
+	if assert.Len(t, filtered.Data, 2) {
+		for i, hit := range filtered.Data {
+			assert.Equal(t, unfiltered.Data[i].Score, hit.Score)
+		}
+	}
+
+	// lines matching filters only all have the same score
+	filtered, err = searcher.Search(SearchOptions{PageNumber: 1, PageSize: 10, Filters: options.Filters})
+	assert.Nil(t, err)
+	if assert.Len(t, filtered.Data, 2) {
+		assert.Equal(t, filtered.Data[0].Score, filtered.Data[1].Score)
+	}
+}
+
+func TestBleveStore_Search_InvalidOptions(t *testing.T) {
+	searcher := newTestStore([]ShakespeareWork{})
+
+	testCases := []struct {
+		name    string
+		options SearchOptions
+	}{
+		{name: "empty clause", options: SearchOptions{Structured: &QueryClause{}}},
+		{name: "multiple clauses", options: SearchOptions{Structured: &QueryClause{
+			Match:  &MatchClause{Text: "a"},
+			Phrase: &PhraseClause{Text: "b"},
+		}}},
+		{name: "empty bool", options: SearchOptions{Structured: &QueryClause{Bool: &BoolClause{}}}},
+		{name: "nested invalid clause", options: SearchOptions{Structured: &QueryClause{Bool: &BoolClause{
+			Must: []QueryClause{{Match: &MatchClause{Text: "a", Operator: "xor"}}},
+		}}}},
+		{name: "fuzziness", options: SearchOptions{Structured: &QueryClause{Match: &MatchClause{Text: "a", Fuzziness: 3}}}},
+		{name: "unknown facet", options: SearchOptions{Facets: map[string]int{"Text": 10}}},
+		{name: "facet size", options: SearchOptions{Facets: map[string]int{"workId": 0}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.options.PageNumber = 1
+			tc.options.PageSize = 10
+			_, err := searcher.Search(tc.options)
+			assert.True(t, errors.Is(err, ErrInvalidSearchOptions))
+		})
+	}
+}
+
+func stripHighlight(line string) string {
+	return strings.NewReplacer("<mark>", "", "</mark>", "").Replace(line)
+}
+
+func TestBleveStore_Search_SpeakerFacet(t *testing.T) {
+	line := func(n int, text string) Line {
+		return Line{LineNumber: n, Text: text}
+	}
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "ROMEO.\nmy love\nJULIET.\nlove me\nlove goes", Markup: &Structure{
+			Acts: []Act{{Number: 1, Scenes: []Scene{{Type: "scene", Number: 1, Blocks: []Block{
+				{Kind: BlockSpeech, Speaker: "ROMEO", Lines: []Line{line(2, "my love")}},
+				{Kind: BlockSpeech, Speaker: "JULIET", Lines: []Line{line(4, "love me")}},
+				{Kind: BlockStage, Lines: []Line{line(5, "love goes")}},
+			}}}}},
+		}},
+	}
+	searcher := newTestStore(data)
+
+	result, err := searcher.Search(SearchOptions{
+		Query:      "love",
+		PageNumber: 1,
+		PageSize:   10,
+		Facets:     map[string]int{"speaker": 10},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]FacetCount{
+		"speaker": {{Count: 1, Term: "JULIET"}, {Count: 1, Term: "ROMEO"}},
+	}, result.Meta.Facets)
+}
@@ -0,0 +1,313 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxQuoteWords is the maximum number of words of a quotation
+	maxQuoteWords = 60
+	// MaxQuoteLimit is the maximum number of passages returned for a quotation
+	MaxQuoteLimit = 10
+	// quoteCandidates is the number of hits of the search re-ranked by alignment
+	quoteCandidates = 20
+	// quoteWordsPerLine is the fewest words per line expected of a quotation, which
+	// sets how many lines around a hit it is aligned with
+	quoteWordsPerLine = 4
+)
+
+// Scores of the alignment of the words of a quotation with the words of a passage
+const (
+	alignMatch    = 2  // the same word
+	alignSimilar  = 1  // a word spelled almost the same, e.g. sleep for sleeps
+	alignMismatch = -1 // another word
+	alignGap      = -1 // a word missing from the quotation or from the passage
+)
+
+// QuoteOptions represents the options to find a quotation
+type QuoteOptions struct {
+	Text    string `query:"text"`
+	WorkID  string `query:"workId"`
+	Edition string `query:"edition"`
+	Limit   int    `query:"limit"` // number of passages returned
+}
+
+// QuoteMatch represents a passage matching a quotation
+type QuoteMatch struct {
+	Citation      string  `json:"citation"` // act.scene.line, e.g. 3.1.56, or the line of works without acts
+	Edition       string  `json:"edition"`
+	EndLineNumber int     `json:"endLineNumber"`
+	LineNumber    int     `json:"lineNumber"` // of the first line, numbered as in /works/:id
+	Score         float64 `json:"score"`      // from 0 to 1 if every word of the quotation is in the passage
+	Text          string  `json:"text"`       // exact text of the passage, with a new line between lines
+	Title         string  `json:"title"`
+	WorkID        string  `json:"workId"`
+}
+
+// Quotation represents the passages best matching a quotation, best first
+type Quotation struct {
+	Matches []QuoteMatch `json:"matches"`
+	Text    string       `json:"text"`
+}
+
+// citedLine is a line of a work with the place it is cited by
+type citedLine struct {
+	Line
+	place  []string // e.g. act and scene, empty for works without acts
+	number int      // number of the line in its scene, or in the work for works without acts
+}
+
+// citation returns the canonical citation of a line, e.g. 3.1.56
+func (l citedLine) citation() string {
+	return strings.Join(append(append([]string{}, l.place...), strconv.Itoa(l.number)), ".")
+}
+
+// scenePlace returns the place of a scene in a play, e.g. 3 and 1 for the first scene of
+// the third act, or 5 and epilogue for an epilogue after the fifth act
+func scenePlace(act Act, scene Scene) []string {
+	var place []string
+	if act.Number > 0 {
+		place = append(place, strconv.Itoa(act.Number))
+	}
+	if scene.Number > 0 {
+		return append(place, strconv.Itoa(scene.Number))
+	}
+	return append(place, scene.Type)
+}
+
+// citedLines returns the lines passages of a work are cited from in text order. The
+// lines of plays are those of speeches, songs and text in scenes, numbered from 1 in
+// each scene, so numbers are close to those of printed editions but may differ, as
+// editions break prose into lines differently. Works without acts have all their lines
+// numbered from 1.
+func citedLines(structure Structure) []citedLine {
+	var lines []citedLine
+	if len(structure.Acts) == 0 {
+		structure.eachBlock(func(block Block) {
+			for _, line := range block.Lines {
+				lines = append(lines, citedLine{Line: line, number: len(lines) + 1})
+			}
+		})
+		return lines
+	}
+	for _, act := range structure.Acts {
+		for _, scene := range act.Scenes {
+			place := scenePlace(act, scene)
+			number := 0
+			for _, block := range scene.Blocks {
+				if blockForm(block) == FormStage {
+					continue
+				}
+				for _, line := range block.Lines {
+					number++
+					lines = append(lines, citedLine{Line: line, place: place, number: number})
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// passageWord is a word of a passage with the index of its line in the passage
+type passageWord struct {
+	token
+	line int
+}
+
+// similarity returns the alignment score of two words
+func similarity(a, b string) int {
+	if a == b {
+		return alignMatch
+	}
+	longest := len([]rune(a))
+	if n := len([]rune(b)); n > longest {
+		longest = n
+	}
+	if longest >= 4 && levenshtein(a, b)*3 <= longest {
+		return alignSimilar
+	}
+	return alignMismatch
+}
+
+// align returns the score of the best local alignment (Smith-Waterman) of the words of
+// a quotation with the words of a passage, and the indexes of the first and last words
+// of the passage aligned. The score is 0 if no word is aligned.
+func align(quotation []string, passage []passageWord) (int, int, int) {
+	prev := make([]int, len(passage)+1)
+	curr := make([]int, len(passage)+1)
+	prevStart := make([]int, len(passage)+1)
+	currStart := make([]int, len(passage)+1)
+	best, first, last := 0, 0, 0
+	for i := 1; i <= len(quotation); i++ {
+		for j := 1; j <= len(passage); j++ {
+			score, start := 0, 0
+			if diagonal := prev[j-1] + similarity(quotation[i-1], passage[j-1].term); diagonal > score {
+				score, start = diagonal, prevStart[j-1]
+				if prev[j-1] == 0 {
+					start = j - 1
+				}
+			}
+			if up := prev[j] + alignGap; up > score {
+				score, start = up, prevStart[j]
+			}
+			if left := curr[j-1] + alignGap; left > score {
+				score, start = left, currStart[j-1]
+			}
+			curr[j], currStart[j] = score, start
+			if score > best {
+				best, first, last = score, start, j-1
+			}
+		}
+		prev, curr = curr, prev
+		prevStart, currStart = currStart, prevStart
+	}
+	return best, first, last
+}
+
+// passageText returns the text of lines from the start of the first word to the end
+// of the last one, with a new line between lines
+func passageText(lines []citedLine, first passageWord, last passageWord) string {
+	if first.line == last.line {
+		return lines[first.line].Text[first.start:last.end]
+	}
+	texts := []string{strings.TrimSpace(lines[first.line].Text[first.start:])}
+	for _, line := range lines[first.line+1 : last.line] {
+		texts = append(texts, strings.TrimSpace(line.Text))
+	}
+	texts = append(texts, strings.TrimSpace(lines[last.line].Text[:last.end]))
+	return strings.Join(texts, "\n")
+}
+
+// alignPassage returns the part of the lines best aligned with a quotation, or false
+// if no word of the quotation is in the lines
+func alignPassage(quotation []string, lines []citedLine) (QuoteMatch, bool) {
+	var passage []passageWord
+	for i, line := range lines {
+		for _, t := range tokenizeWords(line.Text) {
+			passage = append(passage, passageWord{token: t, line: i})
+		}
+	}
+	score, first, last := align(quotation, passage)
+	if score == 0 {
+		return QuoteMatch{}, false
+	}
+	start, end := passage[first], passage[last]
+	return QuoteMatch{
+		Citation:      lines[start.line].citation(),
+		EndLineNumber: lines[end.line].LineNumber,
+		LineNumber:    lines[start.line].LineNumber,
+		Score:         float64(score) / float64(alignMatch*len(quotation)),
+		Text:          passageText(lines, start, end),
+	}, true
+}
+
+// quotePattern returns a regex matching lines with two consecutive words of a quotation,
+// or with its word if it has only one
+func quotePattern(words []string) string {
+	pairs := []string{regexp.QuoteMeta(words[0])}
+	if len(words) > 1 {
+		pairs = nil
+		for i := 1; i < len(words); i++ {
+			pairs = append(pairs, regexp.QuoteMeta(words[i-1])+`\W+`+regexp.QuoteMeta(words[i]))
+		}
+	}
+	return `(?i)\b(?:` + strings.Join(pairs, "|") + `)\b`
+}
+
+// findQuote finds the passages best matching a quotation whose words may be misremembered.
+// The lines of the hits of a search for the words of the quotation are aligned with it
+// along with the lines around them, so that passages can run over several lines, and
+// the passages are re-ranked by the score of their alignment. Quotations of stop words
+// only, e.g. "to be or not to be", find no line in a store ignoring them, so lines with
+// consecutive words of the quotation are searched for instead.
+func (c *corpus) findQuote(options QuoteOptions, search func(SearchOptions) (SearchResult, error)) (Quotation, error) {
+	quotation := Quotation{Matches: []QuoteMatch{}, Text: options.Text}
+	var words []string
+	for _, t := range tokenizeWords(options.Text) {
+		words = append(words, t.term)
+	}
+	if len(words) == 0 {
+		return quotation, invalidOptions("text is required")
+	}
+	if len(words) > maxQuoteWords {
+		return quotation, invalidOptions("text must have at most %d words", maxQuoteWords)
+	}
+	if options.Limit < 1 || options.Limit > MaxQuoteLimit {
+		return quotation, invalidOptions("limit must be between 1 and %d", MaxQuoteLimit)
+	}
+
+	candidates := SearchOptions{
+		Query:      strings.Join(words, " "),
+		WorkID:     options.WorkID,
+		Edition:    options.Edition,
+		PageNumber: 1,
+		PageSize:   quoteCandidates,
+		SortBy:     []string{"-_score"}, // the best candidates, not the first lines matching
+	}
+	result, err := search(candidates)
+	if err == nil && len(result.Data) == 0 {
+		candidates.Query, candidates.Regex = "", quotePattern(words)
+		result, err = search(candidates)
+	}
+	if err != nil {
+		return quotation, err
+	}
+
+	radius := len(words)/quoteWordsPerLine + 1
+	works := make(map[string][]citedLine)
+	seen := make(map[string]bool)
+	for _, hit := range result.Data {
+		key := workKey(hit.WorkID, hit.Edition)
+		lines, ok := works[key]
+		if !ok {
+			work, err := c.GetWorkByEdition(hit.WorkID, hit.Edition)
+			if err != nil {
+				return quotation, err
+			}
+			lines = citedLines(work.Structure())
+			works[key] = lines
+		}
+		i := sort.Search(len(lines), func(i int) bool {
+			return lines[i].LineNumber >= hit.LineNumber
+		})
+		if i == len(lines) || lines[i].LineNumber != hit.LineNumber { // e.g. a heading
+			continue
+		}
+		from, to := i-radius, min(i+radius+1, len(lines))
+		if from < 0 {
+			from = 0
+		}
+		match, ok := alignPassage(words, lines[from:to])
+		id := fmt.Sprintf("%s/%d-%d", key, match.LineNumber, match.EndLineNumber)
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		match.Edition, match.Title, match.WorkID = hit.Edition, hit.Title, hit.WorkID
+		quotation.Matches = append(quotation.Matches, match)
+	}
+	// hits are in the order of the search, which breaks ties
+	sort.SliceStable(quotation.Matches, func(i, j int) bool {
+		return quotation.Matches[i].Score > quotation.Matches[j].Score
+	})
+	if len(quotation.Matches) > options.Limit {
+		quotation.Matches = quotation.Matches[:options.Limit]
+	}
+	return quotation, nil
+}
+
+// Quote finds the passages best matching a quotation with misremembered words, with
+// their canonical citation and exact text
+func (b *BleveStore) Quote(options QuoteOptions) (Quotation, error) {
+	return b.findQuote(options, b.Search)
+}
+
+// Quote finds the passages best matching a quotation with misremembered words, with
+// their canonical citation and exact text
+func (m *MemoryStore) Quote(options QuoteOptions) (Quotation, error) {
+	return m.findQuote(options, m.Search)
+}
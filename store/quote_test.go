@@ -0,0 +1,192 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testQuotePlay = `ACT I
+
+SCENE I. A room in the castle.
+
+ Enter Hamlet.
+
+HAMLET.
+To be, or not to be, that is the question:
+Whether ’tis nobler in the mind to suffer
+The slings and arrows of outrageous fortune,
+Or to take arms against a sea of troubles,
+And by opposing end them? To die—to sleep,
+No more.
+
+ Enter Ophelia.
+
+OPHELIA.
+Good my lord,
+How does your honour for this many a day?`
+
+const testQuotePoem = `Shall I compare thee to a summer’s day?
+Thou art more lovely and more temperate:
+Rough winds do shake the darling buds of May,`
+
+func TestStores_Quote(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "HAMLET", Title: "HAMLET", Content: testQuotePlay},
+		{ID: "SONNET", Title: "SONNET", Content: testQuotePoem},
+	}
+	testCases := []struct {
+		desc     string
+		text     string
+		citation string
+		lines    [2]int
+		expected string
+		exact    bool
+	}{
+		{
+			desc:     "exact",
+			text:     "that is the question",
+			citation: "1.1.1",
+			lines:    [2]int{8, 8},
+			expected: "that is the question",
+			exact:    true,
+		},
+		{
+			desc:     "stop words",
+			text:     "to be or not to be",
+			citation: "1.1.1",
+			lines:    [2]int{8, 8},
+			expected: "To be, or not to be",
+			exact:    true,
+		},
+		{
+			desc:     "across lines",
+			text:     "to be or not to be that is the question whether tis nobler in the mind",
+			citation: "1.1.1",
+			lines:    [2]int{8, 9},
+			expected: "To be, or not to be, that is the question:\nWhether ’tis nobler in the mind",
+			exact:    true,
+		},
+		{
+			desc:     "misremembered",
+			text:     "to take up arms against a sea of trouble",
+			citation: "1.1.4",
+			lines:    [2]int{11, 11},
+			expected: "to take arms against a sea of troubles",
+		},
+		{
+			desc:     "after a stage direction",
+			text:     "how does your honour for this many a day",
+			citation: "1.1.8",
+			lines:    [2]int{19, 19},
+			expected: "How does your honour for this many a day",
+			exact:    true,
+		},
+		{
+			desc:     "without acts",
+			text:     "rough winds shake the darling buds of may",
+			citation: "3",
+			lines:    [2]int{3, 3},
+			expected: "Rough winds do shake the darling buds of May",
+		},
+	}
+
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		for _, tC := range testCases {
+			t.Run(tC.desc, func(t *testing.T) {
+				quotation, err := s.Quote(QuoteOptions{Text: tC.text, Limit: 1})
+				assert.Nil(t, err)
+				assert.Equal(t, tC.text, quotation.Text)
+				if assert.Len(t, quotation.Matches, 1) {
+					match := quotation.Matches[0]
+					assert.Equal(t, tC.citation, match.Citation)
+					assert.Equal(t, tC.lines, [2]int{match.LineNumber, match.EndLineNumber})
+					assert.Equal(t, tC.expected, match.Text)
+					assert.Equal(t, DefaultEdition, match.Edition)
+					if tC.exact {
+						assert.Equal(t, 1.0, match.Score)
+					} else {
+						assert.True(t, match.Score > 0.5 && match.Score < 1)
+					}
+				}
+			})
+		}
+		t.Run("limit", func(t *testing.T) {
+			quotation, err := s.Quote(QuoteOptions{Text: "to", WorkID: "HAMLET", Limit: 2})
+			assert.Nil(t, err)
+			assert.Len(t, quotation.Matches, 2)
+			for _, match := range quotation.Matches {
+				assert.Equal(t, "HAMLET", match.WorkID)
+			}
+		})
+		t.Run("invalid", func(t *testing.T) {
+			for _, options := range []QuoteOptions{
+				{Limit: 1},
+				{Text: "...", Limit: 1},
+				{Text: "to be", Limit: 0},
+				{Text: "to be", Limit: MaxQuoteLimit + 1},
+			} {
+				_, err := s.Quote(options)
+				assert.True(t, errors.Is(err, ErrInvalidSearchOptions))
+			}
+		})
+	})
+}
+
+func TestBleveStore_Quote_BestCandidates(t *testing.T) {
+	// in index order lines are sorted by their id as a string, 1, 10, 11..., so line 90 comes
+	// after more than quoteCandidates lines matching love
+	content := strings.Repeat("my love is true\n", 89) + testQuotePoem
+	searcher := newTestStore([]ShakespeareWork{{ID: "SONNET", Title: "SONNET", Content: content}})
+
+	quotation, err := searcher.Quote(QuoteOptions{Text: "shall I compare thee to a summers day love", Limit: 1})
+	assert.Nil(t, err)
+	if assert.Len(t, quotation.Matches, 1) {
+		assert.Equal(t, 90, quotation.Matches[0].LineNumber)
+		assert.Equal(t, "Shall I compare thee to a summer’s day", quotation.Matches[0].Text)
+	}
+}
+
+func TestQuotePattern(t *testing.T) {
+	testCases := []struct {
+		words    []string
+		expected string
+	}{
+		{words: []string{"to"}, expected: `(?i)\b(?:to)\b`},
+		{words: []string{"to", "be", "or"}, expected: `(?i)\b(?:to\W+be|be\W+or)\b`},
+		{words: []string{"strain'd"}, expected: `(?i)\b(?:strain'd)\b`},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, quotePattern(tc.words))
+	}
+}
+
+func TestAlign(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		quotation []string
+		passage   string
+		score     int
+		first     int
+		last      int
+	}{
+		{desc: "match", quotation: []string{"sea", "of"}, passage: "a sea of troubles", score: 4, first: 1, last: 2},
+		{desc: "similar", quotation: []string{"sea", "of", "trouble"}, passage: "a sea of troubles", score: 5, first: 1, last: 3},
+		{desc: "gap", quotation: []string{"take", "up", "arms"}, passage: "to take arms", score: 3, first: 1, last: 2},
+		{desc: "none", quotation: []string{"sleep"}, passage: "to take arms", score: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var passage []passageWord
+			for _, t := range tokenizeWords(tC.passage) {
+				passage = append(passage, passageWord{token: t})
+			}
+			score, first, last := align(tC.quotation, passage)
+			assert.Equal(t, tC.score, score)
+			assert.Equal(t, tC.first, first)
+			assert.Equal(t, tC.last, last)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"math"
+	"strings"
+)
+
+// Speech is a speech of a character: the lines spoken in a row in a scene
+type Speech struct {
+	Act        int `json:"act"`
+	Scene      int `json:"scene"`
+	LineNumber int `json:"lineNumber"` // of the first line
+	Lines      int `json:"lines"`
+	Words      int `json:"words"`
+}
+
+// CharacterStats represents how much and how a character speaks in a play
+type CharacterStats struct {
+	ID            string  `json:"id"`
+	WorkID        string  `json:"workId"`
+	Name          string  `json:"name"`
+	Lines         int     `json:"lines"`
+	Words         int     `json:"words"`
+	Speeches      int     `json:"speeches"`
+	LongestSpeech *Speech `json:"longestSpeech,omitempty"` // in words, the first one if several are as long
+	Vocabulary    int     `json:"vocabulary"`              // number of distinct words
+	// VocabularyRichness is the number of distinct words divided by the square root of
+	// the number of words (Guiraud's index), which unlike their ratio does not fall as
+	// characters speak more
+	VocabularyRichness float64 `json:"vocabularyRichness"`
+}
+
+// characterStats returns the stats of the characters of the cast in the same order.
+// Words are split by tokenize.
+func (c *cast) characterStats(tokenize func(text string) []token) []CharacterStats {
+	stats := make([]CharacterStats, len(c.characters))
+	vocabularies := make([]map[string]bool, len(c.characters))
+	for i, character := range c.characters {
+		stats[i] = CharacterStats{ID: character.ID, WorkID: character.WorkID, Name: character.Name}
+		vocabularies[i] = make(map[string]bool)
+	}
+	for _, s := range c.speeches {
+		speech := Speech{Act: s.act.Number, Scene: s.scene.Number, LineNumber: s.lines[0].LineNumber, Lines: len(s.lines)}
+		for _, line := range s.lines {
+			for _, t := range tokenize(line.Text) {
+				speech.Words++
+				vocabularies[s.character][t.term] = true
+			}
+		}
+		st := &stats[s.character]
+		st.Lines += speech.Lines
+		st.Words += speech.Words
+		st.Speeches++
+		if st.LongestSpeech == nil || speech.Words > st.LongestSpeech.Words {
+			st.LongestSpeech = &speech
+		}
+	}
+	for i := range stats {
+		stats[i].Vocabulary = len(vocabularies[i])
+		if stats[i].Words > 0 {
+			stats[i].VocabularyRichness = float64(stats[i].Vocabulary) / math.Sqrt(float64(stats[i].Words))
+		}
+	}
+	return stats
+}
+
+// findCharacter returns the index of the character of the cast named name: its id, the
+// name in its id (e.g. lady-macbeth), its name or one of its aliases with or without
+// the final period, ignoring case. It returns -1 if no character has the name.
+func (c *cast) findCharacter(name string) int {
+	name = strings.TrimSpace(name)
+	for i, character := range c.characters {
+		if strings.EqualFold(name, character.ID) ||
+			strings.EqualFold(name, strings.TrimPrefix(character.ID, character.WorkID+".")) ||
+			strings.EqualFold(name, character.Name) {
+			return i
+		}
+	}
+	for i, character := range c.characters {
+		for _, alias := range character.Aliases {
+			if strings.EqualFold(strings.TrimSuffix(name, "."), strings.TrimSuffix(alias, ".")) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// CharacterStats returns the stats of a character of a work in an edition, or in the
+// edition of GetWorkByID if edition is empty. They are computed when the work is indexed.
+func (c *corpus) CharacterStats(workID string, name string, edition string) (CharacterStats, error) {
+	found, err := c.cast(workID, edition)
+	if err != nil {
+		return CharacterStats{}, err
+	}
+	i := found.findCharacter(name)
+	if i < 0 {
+		return CharacterStats{}, ErrCharacterNotFound
+	}
+	return found.stats[i], nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorpus_CharacterStats(t *testing.T) {
+	data := []ShakespeareWork{{ID: "HAMLET", Title: "Hamlet", Content: testCharactersPlay}}
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		stats, err := s.CharacterStats("HAMLET", "Hamlet", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "HAMLET.hamlet", stats.ID)
+		assert.Equal(t, 4, stats.Lines)
+		assert.Equal(t, 23, stats.Words)
+		assert.Equal(t, 2, stats.Speeches, "a stage direction does not end a speech")
+		assert.Equal(t, &Speech{Act: 1, Scene: 2, LineNumber: 30, Lines: 3, Words: 20}, stats.LongestSpeech)
+		assert.Equal(t, 22, stats.Vocabulary)
+		assert.InDelta(t, 22/math.Sqrt(23), stats.VocabularyRichness, 1e-9)
+
+		stats, err = s.CharacterStats("HAMLET", "Ghost", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, stats.Speeches)
+		assert.Nil(t, stats.LongestSpeech)
+		assert.Equal(t, 0.0, stats.VocabularyRichness)
+
+		_, err = s.CharacterStats("HAMLET", "Ophelia", "")
+		assert.True(t, errors.Is(err, ErrCharacterNotFound))
+		_, err = s.CharacterStats("MACBETH", "Macbeth", "")
+		assert.True(t, errors.Is(err, ErrWorkNotFound))
+	})
+}
+
+func TestCast_FindCharacter(t *testing.T) {
+	c := readCast(ShakespeareWork{ID: "HAMLET", Content: testCharactersPlay}, tokenizeWords)
+	testCases := []struct {
+		name     string
+		expected int
+	}{
+		{name: "HAMLET.hamlet", expected: 0},
+		{name: "hamlet", expected: 0},
+		{name: "HAM.", expected: 0},
+		{name: "ham", expected: 0},
+		{name: "Horatio", expected: 1},
+		{name: "HOR", expected: 1},
+		{name: "francisco", expected: 3},
+		{name: "Ophelia", expected: -1},
+		{name: "", expected: -1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, c.findCharacter(tC.name))
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/lang/en"
+)
+
+const (
+	// wordStatsCacheKey is the cache key of the word counts of every work
+	wordStatsCacheKey = "wordStats"
+	// collocationCacheSize is the number of collocation results kept in cache
+	collocationCacheSize = 256
+)
+
+// archaicStopWords are early modern English forms missing from the english stop word list
+var archaicStopWords = []string{
+	"art", "dost", "doth", "hast", "hath", "o", "shalt", "thee", "thine", "thou", "thy", "tis", "’tis", "wilt", "ye",
+}
+
+var stopWords = newStopWords()
+
+func newStopWords() analysis.TokenMap {
+	tokenMap := analysis.NewTokenMap()
+	if err := tokenMap.LoadBytes(en.EnglishStopWords); err != nil {
+		panic(err)
+	}
+	for _, word := range archaicStopWords {
+		tokenMap.AddToken(word)
+	}
+	return tokenMap
+}
+
+func isStopWord(word string) bool {
+	_, ok := stopWords[word]
+	return ok
+}
+
+// isWord reports whether a token contains a letter (numbers are not counted as words)
+func isWord(token string) bool {
+	return strings.IndexFunc(token, unicode.IsLetter) >= 0
+}
+
+// per10k returns the number of occurrences per 10,000 words
+func per10k(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total) * 10000
+}
+
+// wordStats represents the word counts of a work
+type wordStats struct {
+	total  int
+	counts map[string]int
+}
+
+// TermFrequency represents how often a term appears in a work or in the whole corpus
+type TermFrequency struct {
+	Count     int     `json:"count"`
+	Frequency float64 `json:"frequency"` // occurrences per 10,000 words
+	Term      string  `json:"term"`
+}
+
+// WorkTermFrequency represents how often a term appears in a work
+type WorkTermFrequency struct {
+	Count      int     `json:"count"`
+	Frequency  float64 `json:"frequency"` // occurrences per 10,000 words
+	Title      string  `json:"title"`
+	TotalWords int     `json:"totalWords"`
+	WorkID     string  `json:"workId"`
+}
+
+// TermStats represents the number of occurrences of a term in each work of an edition
+type TermStats struct {
+	Count     int                 `json:"count"`
+	Edition   string              `json:"edition"`
+	Frequency float64             `json:"frequency"` // occurrences per 10,000 words
+	Term      string              `json:"term"`
+	Works     []WorkTermFrequency `json:"works"`
+}
+
+// CollocationOptions represents the options to find collocations
+type CollocationOptions struct {
+	Term    string `query:"term"`
+	Window  int    `query:"window"`
+	Top     int    `query:"top"`
+	WorkID  string `query:"workId"`
+	Edition string `query:"edition"` // edition of the work, or DefaultEdition, if empty
+}
+
+// Collocation represents a word appearing near a term
+type Collocation struct {
+	Count int    `json:"count"`
+	Term  string `json:"term"`
+}
+
+// statsEdition returns the edition words are counted in: edition, or else the edition
+// of GetWorkByID for a work, or else DefaultEdition
+func (c *corpus) statsEdition(workID string, edition string) string {
+	if edition != "" {
+		return edition
+	}
+	if workID != "" {
+		if work, err := c.GetWorkByID(workID); err == nil {
+			return work.Edition
+		}
+	}
+	return DefaultEdition
+}
+
+// wordStats returns the word counts of each work keyed by workKey. The counts of the
+// whole corpus in an edition are stored with an empty work id. Counts are computed once
+// from the indexed lines and cached until the next BatchIndex; counts computed while it runs are not reused.
+func (c *corpus) wordStats() map[string]*wordStats {
+	cacheKey := c.cacheKey(wordStatsCacheKey)
+	if cached, ok := c.cache.Load(cacheKey); ok {
+		return cached.(map[string]*wordStats)
+	}
+
+	stats := make(map[string]*wordStats)
+	statsOf := func(key string) *wordStats {
+		ws, ok := stats[key]
+		if !ok {
+			ws = &wordStats{counts: make(map[string]int)}
+			stats[key] = ws
+		}
+		return ws
+	}
+	c.lines.Range(func(key, value interface{}) bool {
+		doc := value.(Document)
+		ws := statsOf(workKey(doc.WorkID, doc.Edition))
+		all := statsOf(workKey("", doc.Edition))
+		for _, word := range c.analyzeWords(doc.Text) {
+			if !isWord(word) {
+				continue
+			}
+			ws.total++
+			all.total++
+			if isStopWord(word) {
+				continue
+			}
+			ws.counts[word]++
+			all.counts[word]++
+		}
+		return true
+	})
+	c.cache.Store(cacheKey, stats)
+	return stats
+}
+
+// TermFrequencies returns the most frequent non-stop words of a work, or of the whole
+// corpus if workID is empty, in an edition chosen by statsEdition
+func (c *corpus) TermFrequencies(workID string, edition string, top int) ([]TermFrequency, error) {
+	edition = c.statsEdition(workID, edition)
+	if workID != "" {
+		if _, err := c.GetWorkByEdition(workID, edition); err != nil {
+			return nil, err
+		}
+	}
+	frequencies := make([]TermFrequency, 0)
+	ws, ok := c.wordStats()[workKey(workID, edition)]
+	if !ok {
+		return frequencies, nil
+	}
+	for term, count := range ws.counts {
+		frequencies = append(frequencies, TermFrequency{
+			Count:     count,
+			Frequency: per10k(count, ws.total),
+			Term:      term,
+		})
+	}
+	sort.Slice(frequencies, func(i, j int) bool {
+		if frequencies[i].Count != frequencies[j].Count {
+			return frequencies[i].Count > frequencies[j].Count
+		}
+		return frequencies[i].Term < frequencies[j].Term
+	})
+	if len(frequencies) > top {
+		frequencies = frequencies[:top]
+	}
+	return frequencies, nil
+}
+
+// TermStats returns the number of occurrences of a term in each work of an edition,
+// DefaultEdition if empty
+func (c *corpus) TermStats(term string, edition string) (TermStats, error) {
+	termStats := TermStats{
+		Edition: c.statsEdition("", edition),
+		Term:    strings.ToLower(term),
+		Works:   make([]WorkTermFrequency, 0),
+	}
+	if words := c.analyzeWords(term); len(words) > 0 {
+		termStats.Term = words[0]
+	}
+
+	stats := c.wordStats()
+	for _, title := range c.ListTitles() {
+		ws, ok := stats[workKey(title.WorkID, termStats.Edition)]
+		if !ok {
+			continue
+		}
+		count := ws.counts[termStats.Term]
+		termStats.Works = append(termStats.Works, WorkTermFrequency{
+			Count:      count,
+			Frequency:  per10k(count, ws.total),
+			Title:      title.Title,
+			TotalWords: ws.total,
+			WorkID:     title.WorkID,
+		})
+	}
+	if all, ok := stats[workKey("", termStats.Edition)]; ok {
+		termStats.Count = all.counts[termStats.Term]
+		termStats.Frequency = per10k(termStats.Count, all.total)
+	}
+	return termStats, nil
+}
+
+// Collocations returns the words appearing most often within a window of words around
+// a term in an edition chosen by statsEdition
+func (c *corpus) Collocations(options CollocationOptions) ([]Collocation, error) {
+	edition := c.statsEdition(options.WorkID, options.Edition)
+	cacheKey := c.cacheKey(fmt.Sprintf("%s:%d:%d:%s", strings.ToLower(options.Term), options.Window, options.Top, workKey(options.WorkID, edition)))
+	if cached, ok := c.collocations.Get(cacheKey); ok {
+		return cached.([]Collocation), nil
+	}
+
+	counts := make(map[string]int)
+	err := c.occurrences(options.Term, options.WorkID, func(docID string, doc Document, occurrences []token) error {
+		if doc.Edition != edition {
+			return nil
+		}
+		keywords := make(map[int]bool, len(occurrences))
+		for _, occurrence := range occurrences {
+			keywords[occurrence.position] = true
+		}
+		// positions of the occurrences match the ones of the tokenized words
+		for _, token := range c.tokenize(doc.Text) {
+			word := token.term
+			if keywords[token.position] || !isWord(word) || isStopWord(word) {
+				continue
+			}
+			for pos := range keywords {
+				if abs(token.position-pos) <= options.Window {
+					counts[word]++
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collocations := make([]Collocation, 0, len(counts))
+	for term, count := range counts {
+		collocations = append(collocations, Collocation{Count: count, Term: term})
+	}
+	sort.Slice(collocations, func(i, j int) bool {
+		if collocations[i].Count != collocations[j].Count {
+			return collocations[i].Count > collocations[j].Count
+		}
+		return collocations[i].Term < collocations[j].Term
+	})
+	if len(collocations) > options.Top {
+		collocations = collocations[:options.Top]
+	}
+	c.collocations.Add(cacheKey, collocations)
+	return collocations, nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatsTestStore() *BleveStore {
+	return newTestStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "my love is as a fever 1\nlove is my fever"},
+		{ID: "2", Title: "TitleB", Content: "love looks not with the eyes but with the mind"},
+		{ID: "1", Title: "TitleA", Content: "my love is as a fever longing still", Edition: "folio"},
+	})
+}
+
+func TestBleveStore_TermFrequencies(t *testing.T) {
+	searcher := newStatsTestStore()
+
+	testCases := []struct {
+		name     string
+		workID   string
+		edition  string
+		top      int
+		expected []TermFrequency
+	}{
+		{
+			name: "corpus",
+			top:  2,
+			expected: []TermFrequency{
+				{Count: 3, Frequency: 1500, Term: "love"},
+				{Count: 2, Frequency: 1000, Term: "fever"},
+			},
+		},
+		{
+			name:   "work",
+			workID: "2",
+			top:    10,
+			expected: []TermFrequency{
+				{Count: 1, Frequency: 1000, Term: "eyes"},
+				{Count: 1, Frequency: 1000, Term: "looks"},
+				{Count: 1, Frequency: 1000, Term: "love"},
+				{Count: 1, Frequency: 1000, Term: "mind"},
+			},
+		},
+		{
+			name:    "edition",
+			edition: "folio",
+			top:     2,
+			expected: []TermFrequency{
+				{Count: 1, Frequency: 1250, Term: "fever"},
+				{Count: 1, Frequency: 1250, Term: "longing"},
+			},
+		},
+		{
+			name:    "work in edition",
+			workID:  "1",
+			edition: "folio",
+			top:     1,
+			expected: []TermFrequency{
+				{Count: 1, Frequency: 1250, Term: "fever"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frequencies, err := searcher.TermFrequencies(tc.workID, tc.edition, tc.top)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, frequencies)
+		})
+	}
+}
+
+func TestBleveStore_TermFrequencies_NotFound(t *testing.T) {
+	searcher := newStatsTestStore()
+
+	_, err := searcher.TermFrequencies("3", "", 10)
+	assert.Equal(t, ErrWorkNotFound, err)
+	_, err = searcher.TermFrequencies("2", "folio", 10)
+	assert.Equal(t, ErrWorkNotFound, err)
+}
+
+func TestBleveStore_TermStats(t *testing.T) {
+	searcher := newStatsTestStore()
+
+	stats, err := searcher.TermStats("Fever", "")
+	assert.Nil(t, err)
+	assert.Equal(t, TermStats{
+		Count:     2,
+		Edition:   DefaultEdition,
+		Frequency: 1000,
+		Term:      "fever",
+		Works: []WorkTermFrequency{
+			{Count: 2, Frequency: 2000, Title: "TitleA", TotalWords: 10, WorkID: "1"},
+			{Count: 0, Frequency: 0, Title: "TitleB", TotalWords: 10, WorkID: "2"},
+		},
+	}, stats)
+
+	stats, err = searcher.TermStats("Fever", "folio")
+	assert.Nil(t, err)
+	assert.Equal(t, TermStats{
+		Count:     1,
+		Edition:   "folio",
+		Frequency: 1250,
+		Term:      "fever",
+		Works: []WorkTermFrequency{
+			{Count: 1, Frequency: 1250, Title: "TitleA", TotalWords: 8, WorkID: "1"},
+		},
+	}, stats)
+}
+
+func TestBleveStore_Collocations(t *testing.T) {
+	searcher := newStatsTestStore()
+
+	testCases := []struct {
+		name     string
+		options  CollocationOptions
+		expected []Collocation
+	}{
+		{
+			name:    "window",
+			options: CollocationOptions{Term: "love", Window: 4, Top: 10},
+			expected: []Collocation{
+				{Count: 2, Term: "fever"},
+				{Count: 1, Term: "looks"},
+			},
+		},
+		{
+			name:    "top",
+			options: CollocationOptions{Term: "love", Window: 4, Top: 1},
+			expected: []Collocation{
+				{Count: 2, Term: "fever"},
+			},
+		},
+		{
+			name:     "specific work",
+			options:  CollocationOptions{Term: "love", Window: 1, Top: 10, WorkID: "2"},
+			expected: []Collocation{{Count: 1, Term: "looks"}},
+		},
+		{
+			name:     "edition",
+			options:  CollocationOptions{Term: "love", Window: 4, Top: 10, Edition: "folio"},
+			expected: []Collocation{{Count: 1, Term: "fever"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			collocations, err := searcher.Collocations(tc.options)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, collocations)
+		})
+	}
+}
+
+func TestBleveStore_Collocations_Cache(t *testing.T) {
+	searcher := newStatsTestStore()
+	for i := 0; i < collocationCacheSize+10; i++ {
+		_, err := searcher.Collocations(CollocationOptions{Term: fmt.Sprintf("love%d", i), Window: 4, Top: 10})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, collocationCacheSize, searcher.collocations.Len())
+
+	assert.Nil(t, searcher.BatchIndex(nil))
+	assert.Equal(t, 0, searcher.collocations.Len())
+}
+
+func TestCorpus_WordStats_WhileIndexing(t *testing.T) {
+	c := newCorpus(tokenizeWords)
+	data := []ShakespeareWork{{ID: "1", Title: "TitleA", Content: "my love is as a fever\nlove is my fever"}}
+	var cacheKey string
+	err := c.addWorks(data, func(docID string, doc Document) error {
+		if cacheKey == "" {
+			cacheKey = c.cacheKey(wordStatsCacheKey)
+		}
+		return nil
+	}, func(docID string, doc Document) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	// counts started while indexing may be stored once the caches are cleared
+	c.cache.Store(cacheKey, map[string]*wordStats{workKey("", DefaultEdition): {total: 1}})
+
+	frequencies, err := c.TermFrequencies("", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []TermFrequency{{Count: 2, Frequency: 2000, Term: "fever"}}, frequencies)
+}
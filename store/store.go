@@ -3,14 +3,19 @@ package store
 import (
 	"errors"
 	"fmt"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/char/regexp"
 	"github.com/blevesearch/bleve/analysis/lang/en"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/porter"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/mapping"
 	"github.com/blevesearch/bleve/search/query"
 	log "github.com/sirupsen/logrus"
@@ -21,8 +26,28 @@ var (
 	ErrWorkNotFound = errors.New("work not found")
 )
 
+const (
+	// DefaultEdition is the edition of works read without one
+	DefaultEdition = "gutenberg"
+	// wordsAnalyzerName is the analyzer used to index unstemmed, lowercased words
+	wordsAnalyzerName = "words"
+	// stageAnalyzerName is the analyzer used to index stage directions
+	stageAnalyzerName = "stage"
+	// scanBatchSize is the number of hits fetched at a time by Scan
+	scanBatchSize = 500
+	// mappingVersion is stored in an index on disk so that an index created with another
+	// mapping is rebuilt rather than reused. Bump it whenever createMapping changes.
+	mappingVersion = "3"
+)
+
+// mappingVersionKey is the internal key of the mapping version of an index
+var mappingVersionKey = []byte("mappingVersion")
+
 func getFragment(frag map[string][]string) string {
 	v, ok := frag["Text"]
+	if !ok {
+		v, ok = frag["StageDirection"]
+	}
 	if !ok {
 		return ""
 	}
@@ -44,12 +69,26 @@ func toZeroPaddedString(n int) string {
 // SearchOptions represents the search options
 // TODO: let user provide highlighter
 type SearchOptions struct {
-	Query      string   `query:"q"`
-	Fuzziness  int      `query:"fuzziness"`
-	WorkID     string   `query:"workId"`
-	PageNumber int      `query:"page[number]"`
-	PageSize   int      `query:"page[size]"`
-	SortBy     []string `query:"sortBy"`
+	Query       string   `query:"q" json:"q"`
+	Fuzziness   int      `query:"fuzziness" json:"fuzziness"`
+	WorkID      string   `query:"workId" json:"workId"`
+	Edition     string   `query:"edition" json:"edition"` // all editions if empty
+	PageNumber  int      `query:"page[number]" json:"page[number]"`
+	PageSize    int      `query:"page[size]" json:"page[size]"`
+	SortBy      []string `query:"sortBy" json:"sortBy"`
+	Autocorrect bool     `query:"autocorrect" json:"autocorrect"`
+	Mode        string   `query:"mode" json:"mode"`   // ModeWords if empty
+	Regex       string   `query:"regex" json:"regex"` // pattern of a regex search, instead of q
+	Form        string   `query:"form" json:"form"`   // lines of the form: verse, prose, song or stage
+	Meter       string   `query:"meter" json:"meter"` // lines scanning as the meter, e.g. iambic-pentameter
+	Rhyme       string   `query:"rhyme" json:"rhyme"` // lines ending on a rhyme with the word
+	In          string   `query:"in" json:"in"`       // searched lines: text (default) or stage directions
+	// structured queries, filters and facets are only available with a JSON body
+	Structured *QueryClause   `query:"-" json:"query,omitempty"`
+	Filters    Filters        `query:"-" json:"filters"`
+	Facets     map[string]int `query:"-" json:"facets,omitempty"` // facet name to number of terms
+
+	rhymeKey string // rhyme key of Rhyme, set by the store
 }
 
 // Offset returns the number of records that will be skipped
@@ -57,10 +96,10 @@ func (s *SearchOptions) Offset() int {
 	return (s.PageNumber - 1) * s.PageSize
 }
 
-// SortBySlice returns a slice of orders(field and direction) to sort the search result
-// it seems that the fiber queryparser failed to parse ","" delimited query params
-// correctly for mixedcase param name so this function is created to ensure that
-// the parameter is properly parsedd
+// SortBySlice returns a slice of orders(field and direction) to sort the search result.
+// The fiber query parser only splits "," delimited query params whose lowercased field
+// name equals the param name, so sortBy=Title,LineNumber is parsed as a single order and
+// is split here. Options decoded from a JSON body already have one order per item.
 func (s *SearchOptions) SortBySlice() []string {
 	var sortBy []string
 	for _, term := range s.SortBy {
@@ -71,10 +110,14 @@ func (s *SearchOptions) SortBySlice() []string {
 
 // Meta represents non-standard meta-information in SearchResult
 type Meta struct {
-	Highlight    Highlight `json:"highlight"`
-	PageNumber   int       `json:"pageNumber"`
-	PageSize     int       `json:"pageSize"`
-	TotalResults int       `json:"totalResults"`
+	CorrectedQuery string                  `json:"correctedQuery,omitempty"`
+	Facets         map[string][]FacetCount `json:"facets,omitempty"`
+	Highlight      Highlight               `json:"highlight"`
+	PageNumber     int                     `json:"pageNumber"`
+	PageSize       int                     `json:"pageSize"`
+	Suggestions    []Suggestion            `json:"suggestions,omitempty"`
+	TotalResults   int                     `json:"totalResults"`
+	Truncated      bool                    `json:"truncated,omitempty"` // TotalResults is capped, more lines match
 }
 
 // Highlight represents the search highlight related information
@@ -85,6 +128,7 @@ type Highlight struct {
 
 // Hit represents matched document(a single line)
 type Hit struct {
+	Edition    string  `json:"edition,omitempty"`
 	Line       string  `json:"line"`
 	LineNumber int     `json:"lineNumber"`
 	Score      float64 `json:"score"`
@@ -102,76 +146,108 @@ type SearchResult struct {
 // ShakespeareWork represents Shakespeare's work(poem, play, sonnet, ...)
 type ShakespeareWork struct {
 	ID      string `json:"id"`
+	Edition string `json:"edition,omitempty"` // text the work is read from, e.g. gutenberg or folger
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	Year    int    `json:"year,omitempty"` // year of composition
+	// Markup is the structure given by the markup of the source (e.g. TEI).
+	// The structure is detected from Content if it is nil.
+	Markup *Structure `json:"-"`
+}
+
+// Line represents a non-empty line of Shakespeare's work
+type Line struct {
+	LineNumber int    `json:"lineNumber"`
+	Text       string `json:"text"`
+}
+
+// Lines returns the non-empty lines of the work numbered the same way they are indexed
+func (w ShakespeareWork) Lines() []Line {
+	var lines []Line
+	for i, text := range strings.Split(w.Content, "\n") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, Line{LineNumber: i + 1, Text: text})
+	}
+	return lines
 }
 
 // Title represents a title of Shakespeare's work
 type Title struct {
-	Title  string `json:"title"`
-	WorkID string `json:"workId"`
+	Editions []string `json:"editions,omitempty"`
+	Title    string   `json:"title"`
+	WorkID   string   `json:"workId"`
 }
 
 // Document represents a single line of Shakespeare's work
 type Document struct {
+	Edition    string
+	Form       string // verse, prose, song or stage, empty for headings
+	Kind       string // kind of the block of the line (speech, stage or text), empty for headings
 	LineNumber string
-	Text       string
-	Title      string
-	WorkID     string
+	Meter      []string // every meter the line scans as, e.g. iambic-pentameter
+	Rhyme      string   // rhyme key of the last word of the line
+	Speaker    string
+	// StageDirection is the text of a stage direction, kept out of Text so that it is
+	// analyzed on its own and only searched with in=stage
+	StageDirection string
+	Text           string // spoken or printed text, empty for stage directions
+	Title          string
+	WorkID         string
+}
+
+// line returns the text of the line, whether a stage direction or not
+func (d Document) line() string {
+	if d.StageDirection != "" {
+		return d.StageDirection
+	}
+	return d.Text
 }
 
 // BleveStore implements methods to find and search Shakespeare's works
 type BleveStore struct {
+	*corpus
 	index bleve.Index
-	works *sync.Map
-	lines *sync.Map
 }
 
-// BatchIndex batch inserts and indexes a slice of ShakespeareWork
+// BatchIndex batch inserts and indexes a slice of ShakespeareWork. Works without an
+// edition are in DefaultEdition, and a work replaces the one with the same ID and edition.
+// Lines of a work have consecutive document ids.
 func (b *BleveStore) BatchIndex(data []ShakespeareWork) error {
 	batchSize := 10000
-	batchCount := 1
-	count := 1
+	batchCount := 0
 	batch := b.index.NewBatch()
-	for _, work := range data {
-		b.works.Store(work.ID, work)
-		for i, line := range strings.Split(work.Content, "\n") {
-			if strings.TrimSpace(line) == "" {
-				continue
-			}
-			docID := strconv.Itoa(count)
-			doc := Document{
-				LineNumber: toZeroPaddedString(i + 1),
-				Text:       line,
-				Title:      work.Title,
-				WorkID:     work.ID,
-			}
-			if err := batch.Index(docID, doc); err != nil {
+	err := b.addWorks(data, func(docID string, doc Document) error {
+		if err := batch.Index(docID, doc); err != nil {
+			return err
+		}
+		batchCount++
+		if batchCount >= batchSize {
+			if err := b.index.Batch(batch); err != nil {
 				return err
 			}
-			b.lines.Store(docID, doc)
-			batchCount++
-			count++
-			if batchCount >= batchSize {
-				if err := b.index.Batch(batch); err != nil {
-					return err
-				}
-				batch = b.index.NewBatch()
-				batchCount = 0
-			}
+			batch = b.index.NewBatch()
+			batchCount = 0
 		}
-		log.Infof("Indexed: %s, (%d docs)", work.Title, count)
+		return nil
+	}, func(docID string, doc Document) error {
+		batch.Delete(docID)
+		batchCount++
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	if batchCount > 0 {
-		if err := b.index.Batch(batch); err != nil {
-			return err
-		}
+		return b.index.Batch(batch)
 	}
 	return nil
 }
 
 func (b *BleveStore) parseResult(result *bleve.SearchResult, v *SearchResult) error {
 	v.Meta.TotalResults = int(result.Total)
+	v.Meta.Facets = parseFacets(result.Facets)
 	for _, hit := range result.Hits {
 		found, ok := b.lines.Load(hit.ID)
 		if !ok {
@@ -183,7 +259,7 @@ func (b *BleveStore) parseResult(result *bleve.SearchResult, v *SearchResult) er
 		}
 		line := getFragment(hit.Fragments)
 		if line == "" {
-			line = doc.Text
+			line = doc.line()
 		}
 
 		lineNumber, err := parseZeroPaddedNumber(doc.LineNumber)
@@ -194,6 +270,7 @@ func (b *BleveStore) parseResult(result *bleve.SearchResult, v *SearchResult) er
 		v.Data = append(
 			v.Data,
 			Hit{
+				Edition:    doc.Edition,
 				Score:      hit.Score,
 				Line:       line,
 				LineNumber: lineNumber,
@@ -208,17 +285,14 @@ func (b *BleveStore) parseResult(result *bleve.SearchResult, v *SearchResult) er
 
 // Search searches indexed documents using the search options provided
 func (b *BleveStore) Search(options SearchOptions) (SearchResult, error) {
-	searchResult := SearchResult{
-		Data: make([]Hit, 0), // serialized to [] not null for easier parsing.
-		Meta: Meta{
-			Highlight: Highlight{
-				PreTag:  "<mark>",
-				PostTag: "</mark>",
-			},
-			PageNumber: options.PageNumber,
-			PageSize:   options.PageSize,
-		},
+	options, err := b.prepare(options)
+	if err != nil {
+		return newSearchResult(options), err
 	}
+	if isTextSearch(options) {
+		return b.searchText(options)
+	}
+	searchResult := newSearchResult(options)
 
 	req, err := newSearchRequest(options)
 	if err != nil {
@@ -231,14 +305,73 @@ func (b *BleveStore) Search(options SearchOptions) (SearchResult, error) {
 	if err := b.parseResult(result, &searchResult); err != nil {
 		return searchResult, err
 	}
+	return b.withSuggestions(options, searchResult, b.dictionary, b.Search)
+}
+
+// dictionary visits the words of the Words field
+func (b *BleveStore) dictionary(visit func(word string, count uint64)) error {
+	dict, err := b.index.FieldDict("Words")
+	if err != nil {
+		return err
+	}
+	defer dict.Close()
 
-	return searchResult, nil
+	entry, err := dict.Next()
+	for err == nil && entry != nil {
+		visit(entry.Term, entry.Count)
+		entry, err = dict.Next()
+	}
+	return err
+}
+
+// Scan calls fn for every hit matching the options in their sort order, ignoring the
+// page options and facets. Hits are fetched scanBatchSize at a time so the whole result
+// set is never held in memory, and scanning stops at the first error returned by fn.
+func (b *BleveStore) Scan(options SearchOptions, fn func(Hit) error) error {
+	options, err := b.prepare(options)
+	if err != nil {
+		return err
+	}
+	if isTextSearch(options) {
+		return b.scanText(options, fn)
+	}
+	options.PageNumber = 1
+	options.PageSize = scanBatchSize
+	options.Facets = nil
+	// the document id makes the sort keys unique so that no hit is skipped between batches
+	options.SortBy = append(options.SortBySlice(), "_id")
+
+	var after []string
+	for {
+		req, err := newSearchRequest(options)
+		if err != nil {
+			return err
+		}
+		req.SearchAfter = after
+		result, err := b.index.Search(req)
+		if err != nil {
+			return err
+		}
+		var batch SearchResult
+		if err := b.parseResult(result, &batch); err != nil {
+			return err
+		}
+		for _, hit := range batch.Data {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+		if len(result.Hits) < scanBatchSize {
+			return nil
+		}
+		after = result.Hits[len(result.Hits)-1].Sort
+	}
 }
 
-// GetWorkByID returns a ShakespeareWork with matching id
-func (b *BleveStore) GetWorkByID(id string) (ShakespeareWork, error) {
+// GetWorkByEdition returns a ShakespeareWork with matching id in the edition
+func (c *corpus) GetWorkByEdition(id string, edition string) (ShakespeareWork, error) {
 	var work ShakespeareWork
-	found, ok := b.works.Load(id)
+	found, ok := c.works.Load(workKey(id, edition))
 	if !ok {
 		return work, ErrWorkNotFound
 	}
@@ -249,15 +382,51 @@ func (b *BleveStore) GetWorkByID(id string) (ShakespeareWork, error) {
 	return work, nil
 }
 
-// ListTitles returns a slice of work titles
-func (b *BleveStore) ListTitles() []Title {
-	var titles []Title
+// GetWorkByID returns a ShakespeareWork with matching id in DefaultEdition, or else
+// in the first edition (by name) having it
+func (c *corpus) GetWorkByID(id string) (ShakespeareWork, error) {
+	work, err := c.GetWorkByEdition(id, DefaultEdition)
+	if !errors.Is(err, ErrWorkNotFound) {
+		return work, err
+	}
+	var editions []string
+	c.works.Range(func(key, value interface{}) bool {
+		if work := value.(ShakespeareWork); work.ID == id {
+			editions = append(editions, work.Edition)
+		}
+		return true
+	})
+	if len(editions) == 0 {
+		return work, ErrWorkNotFound
+	}
+	sort.Strings(editions)
+	return c.GetWorkByEdition(id, editions[0])
+}
 
-	b.works.Range(func(key, value interface{}) bool {
+// ListTitles returns a slice of work titles with the editions of each work. The
+// title is the one of DefaultEdition if the work is in it.
+func (c *corpus) ListTitles() []Title {
+	byID := make(map[string]*Title)
+
+	c.works.Range(func(key, value interface{}) bool {
 		work := value.(ShakespeareWork)
-		titles = append(titles, Title{Title: work.Title, WorkID: work.ID})
+		title, ok := byID[work.ID]
+		if !ok {
+			title = &Title{Title: work.Title, WorkID: work.ID}
+			byID[work.ID] = title
+		}
+		if work.Edition == DefaultEdition {
+			title.Title = work.Title
+		}
+		title.Editions = append(title.Editions, work.Edition)
 		return true
 	})
+
+	titles := make([]Title, 0, len(byID))
+	for _, title := range byID {
+		sort.Strings(title.Editions)
+		titles = append(titles, *title)
+	}
 	sort.Slice(titles, func(i, j int) bool {
 		return titles[i].Title < titles[j].Title
 	})
@@ -265,27 +434,55 @@ func (b *BleveStore) ListTitles() []Title {
 }
 
 func newSearchRequest(options SearchOptions) (*bleve.SearchRequest, error) {
-	var searchQuery query.Query
-	if options.Query == "" {
-		searchQuery = bleve.NewMatchAllQuery()
-	} else {
-		var queries []query.Query
+	var queries []query.Query
+	field := textField(options)
+	if options.Query != "" {
+		var terms []query.Query
 		for _, term := range strings.Fields(options.Query) {
 			matchQuery := bleve.NewMatchQuery(term)
-			matchQuery.SetField("Text")
+			matchQuery.SetField(field)
 			matchQuery.SetFuzziness(options.Fuzziness)
-			queries = append(queries, matchQuery)
+			terms = append(terms, matchQuery)
 		}
-		searchQuery = bleve.NewDisjunctionQuery(queries...)
+		queries = append(queries, bleve.NewDisjunctionQuery(terms...))
 	}
+	if options.Structured != nil {
+		structuredQuery, err := options.Structured.toQuery(field)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, structuredQuery)
+	}
+	// lines are filtered without changing their score, which only depends on the text
+	var filters []query.Query
 	if options.WorkID != "" {
-		idQuery := bleve.NewTermQuery(options.WorkID)
-		idQuery.SetField("WorkID")
-		searchQuery = bleve.NewConjunctionQuery(
-			searchQuery,
-			idQuery,
-		)
+		filters = append(filters, termFilter("WorkID", options.WorkID))
+	}
+	if options.Edition != "" {
+		filters = append(filters, termFilter("Edition", options.Edition))
+	}
+	// stage directions are only searched with in=stage
+	if inStage(options) {
+		filters = append(filters, termFilter("Form", FormStage))
+	} else {
+		filters = append(filters, notFilter(termFilter("Form", FormStage)))
 	}
+	if options.Form != "" && options.Form != FormStage {
+		filters = append(filters, termFilter("Form", options.Form))
+	}
+	if options.Meter != "" {
+		filters = append(filters, termFilter("Meter", options.Meter))
+	}
+	if options.rhymeKey != "" {
+		filters = append(filters, termFilter("Rhyme", options.rhymeKey))
+	}
+	filters = append(filters, options.Filters.toQueries()...)
+	if len(queries) == 0 {
+		// a conjunction of filters only would have no weight to normalize scores by
+		queries = append(queries, bleve.NewMatchAllQuery())
+	}
+	// there is always a filter on the form of lines besides the scoring query
+	searchQuery := bleve.NewConjunctionQuery(append(queries, filters...)...)
 
 	req := bleve.NewSearchRequestOptions(
 		searchQuery,
@@ -295,36 +492,118 @@ func newSearchRequest(options SearchOptions) (*bleve.SearchRequest, error) {
 	)
 	req.SortBy(options.SortBySlice())
 	req.Highlight = bleve.NewHighlight()
+	if err := addFacets(req, options.Facets); err != nil {
+		return nil, err
+	}
 	return req, nil
 }
 
-func createMapping() mapping.IndexMapping {
+func createMapping() (mapping.IndexMapping, error) {
+	mapping := bleve.NewIndexMapping()
+	err := mapping.AddCustomAnalyzer(wordsAnalyzerName, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// stage directions are set apart by brackets and underscores (italics), which the
+	// unicode tokenizer would keep in words, e.g. _Exit
+	err = mapping.AddCustomCharFilter("stage_markup", map[string]interface{}{
+		"type":    regexp.Name,
+		"regexp":  `[\[\]_]`,
+		"replace": " ",
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = mapping.AddCustomAnalyzer(stageAnalyzerName, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{"stage_markup"},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name, en.StopName, porter.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	keywordFieldMapping := bleve.NewTextFieldMapping()
 	keywordFieldMapping.Analyzer = keyword.Name
 	textFieldMapping := bleve.NewTextFieldMapping()
 	textFieldMapping.Analyzer = en.AnalyzerName
-
-	mapping := bleve.NewIndexMapping()
+	// indexes the same text without stemming so the dictionary holds real words
+	wordsFieldMapping := bleve.NewTextFieldMapping()
+	wordsFieldMapping.Name = "Words"
+	wordsFieldMapping.Analyzer = wordsAnalyzerName
+	wordsFieldMapping.Store = false
+	wordsFieldMapping.IncludeInAll = false
+	wordsFieldMapping.IncludeTermVectors = false
+	stageFieldMapping := bleve.NewTextFieldMapping()
+	stageFieldMapping.Analyzer = stageAnalyzerName
+
+	mapping.DefaultMapping.AddFieldMappingsAt("Edition", keywordFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("Form", keywordFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("Kind", keywordFieldMapping)
 	mapping.DefaultMapping.AddFieldMappingsAt("LineNumber", keywordFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("Meter", keywordFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("Rhyme", keywordFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("Speaker", keywordFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("StageDirection", stageFieldMapping)
 	mapping.DefaultMapping.AddFieldMappingsAt("Title", keywordFieldMapping)
-	mapping.DefaultMapping.AddFieldMappingsAt("Text", textFieldMapping)
+	mapping.DefaultMapping.AddFieldMappingsAt("Text", textFieldMapping, wordsFieldMapping)
 	mapping.DefaultMapping.AddFieldMappingsAt("WorkID", keywordFieldMapping)
 
-	return mapping
+	return mapping, nil
 }
 
 func createIndex(useInMemory bool) (bleve.Index, error) {
-	mapping := createMapping()
+	mapping, err := createMapping()
+	if err != nil {
+		return nil, err
+	}
 
 	if useInMemory {
 		return bleve.NewMemOnly(mapping)
 	}
-	indexPath := "shakesearch.bleve"
-	index, err := bleve.New(indexPath, mapping)
-	if err != nil { // path already exists
-		return bleve.Open(indexPath)
+	return openIndex("shakesearch.bleve", mapping)
+}
+
+// openIndex opens the index at a path, or creates it with the mapping if the path does
+// not exist. An index created with another version of the mapping is removed and
+// created again, as its fields would be analyzed differently.
+func openIndex(indexPath string, mapping mapping.IndexMapping) (bleve.Index, error) {
+	index, err := bleve.Open(indexPath)
+	switch {
+	case err == nil:
+		version, err := index.GetInternal(mappingVersionKey)
+		if err != nil {
+			index.Close()
+			return nil, err
+		}
+		if string(version) == mappingVersion {
+			return index, nil
+		}
+		log.Infof("Rebuilding %s created with mapping version %q", indexPath, version)
+		if err := index.Close(); err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(indexPath); err != nil {
+			return nil, err
+		}
+	case err != bleve.ErrorIndexPathDoesNotExist:
+		return nil, err
 	}
-	return index, err
+
+	index, err = bleve.New(indexPath, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if err := index.SetInternal(mappingVersionKey, []byte(mappingVersion)); err != nil {
+		index.Close()
+		return nil, err
+	}
+	return index, nil
 }
 
 // NewBleveStore creates a new Bleve based store
@@ -333,10 +612,21 @@ func NewBleveStore(useInMemory bool) (*BleveStore, error) {
 	if err != nil {
 		return nil, err
 	}
+	analyzer := index.Mapping().AnalyzerNamed(wordsAnalyzerName)
+	if analyzer == nil {
+		index.Close()
+		return nil, fmt.Errorf("index has no %s analyzer", wordsAnalyzerName)
+	}
 	s := &BleveStore{
+		corpus: newCorpus(func(text string) []token {
+			var tokens []token
+			for _, t := range analyzer.Analyze([]byte(text)) {
+				tokens = append(tokens, token{term: string(t.Term), start: t.Start, end: t.End, position: t.Position})
+			}
+			return tokens
+		}),
 		index: index,
-		works: new(sync.Map),
-		lines: new(sync.Map),
 	}
+	s.occurrences = s.eachOccurrence
 	return s, nil
 }
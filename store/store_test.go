@@ -1,13 +1,20 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/blevesearch/bleve"
 	"github.com/blevesearch/bleve/search"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/prosody"
 )
 
 func init() {
@@ -25,6 +32,40 @@ func newTestStore(data []ShakespeareWork) *BleveStore {
 	return searcher
 }
 
+// testStore is the API both backends implement, for tests run on each of them
+type testStore interface {
+	BatchIndex([]ShakespeareWork) error
+	UsePronunciations(dict prosody.Dictionary)
+	Search(SearchOptions) (SearchResult, error)
+	Scan(SearchOptions, func(Hit) error) error
+	TermFrequencies(workID string, edition string, top int) ([]TermFrequency, error)
+	CharacterStats(workID string, name string, edition string) (CharacterStats, error)
+	Quote(QuoteOptions) (Quotation, error)
+}
+
+// testStoreNames are the names of the backends, in the order tests run on them
+var testStoreNames = []string{"bleve", "memory"}
+
+// newTestStores returns a store of each backend with data indexed, keyed by name
+func newTestStores(data []ShakespeareWork) map[string]testStore {
+	return map[string]testStore{
+		"bleve":  newTestStore(data),
+		"memory": newTestMemoryStore(data),
+	}
+}
+
+// forEachStore runs fn in a subtest named after each backend, with a store of the
+// backend with data indexed
+func forEachStore(t *testing.T, data []ShakespeareWork, fn func(t *testing.T, s testStore)) {
+	stores := newTestStores(data)
+	for _, name := range testStoreNames {
+		s := stores[name]
+		t.Run(name, func(t *testing.T) {
+			fn(t, s)
+		})
+	}
+}
+
 func TestBleveStore_ParseResult(t *testing.T) {
 	data := []ShakespeareWork{
 		{ID: "1", Title: "Title1", Content: "content1"},
@@ -61,8 +102,8 @@ func TestBleveStore_ParseResult(t *testing.T) {
 			},
 			total: 2,
 			expected: []Hit{
-				{Line: "fragment", LineNumber: 1, Score: 1.0, Title: "Title1", WorkID: "1"},
-				{Line: "fragment", LineNumber: 1, Score: 1.0, Title: "Title2", WorkID: "2"},
+				{Edition: DefaultEdition, Line: "fragment", LineNumber: 1, Score: 1.0, Title: "Title1", WorkID: "1"},
+				{Edition: DefaultEdition, Line: "fragment", LineNumber: 1, Score: 1.0, Title: "Title2", WorkID: "2"},
 			},
 		},
 		{
@@ -79,7 +120,7 @@ func TestBleveStore_ParseResult(t *testing.T) {
 			},
 			total: 1,
 			expected: []Hit{
-				{Line: "content1", LineNumber: 1, Score: 1.0, Title: "Title1", WorkID: "1"},
+				{Edition: DefaultEdition, Line: "content1", LineNumber: 1, Score: 1.0, Title: "Title1", WorkID: "1"},
 			},
 		},
 	}
@@ -222,6 +263,40 @@ func TestBleveStore_Search_SortBy(t *testing.T) {
 
 }
 
+func TestBleveStore_Scan(t *testing.T) {
+	var lines []string
+	for i := 1; i <= scanBatchSize*2+10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: strings.Join(lines, "\n")},
+		{ID: "2", Title: "TitleB", Content: "line"},
+	}
+	searcher := newTestStore(data)
+
+	var lineNumbers []int
+	err := searcher.Scan(SearchOptions{Query: "line", WorkID: "1", SortBy: []string{"-LineNumber"}}, func(hit Hit) error {
+		lineNumbers = append(lineNumbers, hit.LineNumber)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(lines), len(lineNumbers))
+	for i, lineNumber := range lineNumbers {
+		assert.Equal(t, len(lines)-i, lineNumber)
+	}
+
+	count := 0
+	err = searcher.Scan(SearchOptions{Query: "line"}, func(hit Hit) error {
+		count++
+		if count == 3 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 3, count)
+}
+
 func TestBleveStore_GetWorkByID(t *testing.T) {
 	data := []ShakespeareWork{
 		{ID: "1", Title: "TitleA", Content: "content"},
@@ -231,7 +306,111 @@ func TestBleveStore_GetWorkByID(t *testing.T) {
 
 	work, err := searcher.GetWorkByID("1")
 	assert.Nil(t, err)
-	assert.Equal(t, data[0], work)
+	expected := data[0]
+	expected.Edition = DefaultEdition
+	assert.Equal(t, expected, work)
+}
+
+func TestBleveStore_Editions(t *testing.T) {
+	searcher := newTestStore([]ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "the quality of mercy"},
+		{ID: "2", Title: "TitleB", Content: "a rose by any other name"},
+	})
+	// editions indexed later must not replace the lines of the first batch
+	err := searcher.BatchIndex([]ShakespeareWork{
+		{ID: "1", Edition: "folio", Title: "Folio TitleA", Content: "the quality of mercie"},
+		{ID: "3", Edition: "folio", Title: "TitleC", Content: "all the world's a stage"},
+	})
+	assert.Nil(t, err)
+
+	work, err := searcher.GetWorkByEdition("1", "folio")
+	assert.Nil(t, err)
+	assert.Equal(t, "the quality of mercie", work.Content)
+	_, err = searcher.GetWorkByEdition("2", "folio")
+	assert.Equal(t, ErrWorkNotFound, err)
+
+	work, err = searcher.GetWorkByID("1")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultEdition, work.Edition)
+	work, err = searcher.GetWorkByID("3")
+	assert.Nil(t, err)
+	assert.Equal(t, "folio", work.Edition)
+
+	assert.Equal(t, []Title{
+		{Editions: []string{"folio", DefaultEdition}, Title: "TitleA", WorkID: "1"},
+		{Editions: []string{DefaultEdition}, Title: "TitleB", WorkID: "2"},
+		{Editions: []string{"folio"}, Title: "TitleC", WorkID: "3"},
+	}, searcher.ListTitles())
+
+	testCases := []struct {
+		edition  string
+		expected []string
+	}{
+		{edition: "", expected: []string{"folio", DefaultEdition}},
+		{edition: DefaultEdition, expected: []string{DefaultEdition}},
+		{edition: "folio", expected: []string{"folio"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.edition, func(t *testing.T) {
+			result, err := searcher.Search(SearchOptions{
+				Query:      "quality",
+				Edition:    tC.edition,
+				PageNumber: 1,
+				PageSize:   10,
+				SortBy:     []string{"Edition"},
+			})
+			assert.Nil(t, err)
+			var got []string
+			for _, hit := range result.Data {
+				got = append(got, hit.Edition)
+			}
+			assert.Equal(t, tC.expected, got)
+		})
+	}
+}
+
+func TestStores_Reindex(t *testing.T) {
+	data := []ShakespeareWork{{ID: "1", Title: "TitleA", Content: "the quality of mercy\nis not strained"}}
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		// the same work indexed again replaces its lines
+		assert.Nil(t, s.BatchIndex(data))
+		assert.Nil(t, s.BatchIndex([]ShakespeareWork{{ID: "1", Title: "TitleA", Content: "the quality of mercy"}}))
+		for _, options := range []SearchOptions{
+			{Query: "mercy"},
+			{Query: "merc", Mode: ModeSubstring},
+			{},
+		} {
+			options.PageNumber = 1
+			options.PageSize = 10
+			result, err := s.Search(options)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, result.Meta.TotalResults)
+		}
+		result, err := s.Search(SearchOptions{Query: "strained", PageNumber: 1, PageSize: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, result.Meta.TotalResults)
+		frequencies, err := s.TermFrequencies("", "", 10)
+		assert.Nil(t, err)
+		for _, frequency := range frequencies {
+			assert.Equal(t, 1, frequency.Count, frequency.Term)
+		}
+	})
+}
+
+func TestStores_BlankQuery(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "the quality of mercy\nis not strained"},
+	}
+	forEachStore(t, data, func(t *testing.T, s testStore) {
+		for _, query := range []string{"", " ", "\t\n"} {
+			result, err := s.Search(SearchOptions{Query: query, PageNumber: 1, PageSize: 10})
+			assert.Nil(t, err)
+			assert.Equal(t, 2, result.Meta.TotalResults, "%q", query)
+		}
+		result, err := s.Search(SearchOptions{Query: " mercy ", PageNumber: 1, PageSize: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, result.Meta.TotalResults)
+	})
 }
 
 func TestBleveStore_GetWorkByID_NotFound(t *testing.T) {
@@ -256,3 +435,150 @@ func TestBleveStore_ListTitles(t *testing.T) {
 	}
 	assert.Equal(t, []string{"TitleA", "TitleB"}, names)
 }
+
+func TestShakespeareWork_Lines(t *testing.T) {
+	work := ShakespeareWork{Content: "\nfirst line\n\n  \nsecond line"}
+	expected := []Line{
+		{LineNumber: 2, Text: "first line"},
+		{LineNumber: 5, Text: "second line"},
+	}
+	assert.Equal(t, expected, work.Lines())
+}
+
+func TestOpenIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shakesearch")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	indexPath := filepath.Join(dir, "test.bleve")
+	mapping, err := createMapping()
+	if err != nil {
+		panic(err)
+	}
+
+	// an index created before the mapping was versioned
+	old, err := bleve.New(indexPath, bleve.NewIndexMapping())
+	if err != nil {
+		panic(err)
+	}
+	assert.Nil(t, old.Index("1", Document{Text: "mercy"}))
+	assert.Nil(t, old.Close())
+
+	index, err := openIndex(indexPath, mapping)
+	assert.Nil(t, err)
+	assert.NotNil(t, index.Mapping().AnalyzerNamed(wordsAnalyzerName))
+	count, err := index.DocCount()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), count)
+	assert.Nil(t, index.Index("1", Document{Text: "mercy"}))
+	assert.Nil(t, index.Close())
+
+	// an index of the current mapping is reused
+	index, err = openIndex(indexPath, mapping)
+	assert.Nil(t, err)
+	count, err = index.DocCount()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), count)
+	assert.Nil(t, index.Close())
+}
+
+// benchmarkWords are the words the lines of the corpus of benchmarks are made of
+var benchmarkWords = strings.Fields(`to be or not that is the question whether tis nobler in mind
+suffer slings and arrows of outrageous fortune take arms against a sea troubles by opposing
+end them die sleep no more say we heart ache thousand natural shocks flesh heir`)
+
+// newBenchmarkCorpus returns works of lines of words of benchmarkWords, the same for every run
+func newBenchmarkCorpus(works, lines int) []ShakespeareWork {
+	data := make([]ShakespeareWork, works)
+	n := 0
+	for i := range data {
+		var content strings.Builder
+		for j := 0; j < lines; j++ {
+			for k := 0; k < 8; k++ {
+				n = (n*31 + 7) % 1009
+				if k > 0 {
+					content.WriteString(" ")
+				}
+				content.WriteString(benchmarkWords[n%len(benchmarkWords)])
+			}
+			content.WriteString("\n")
+		}
+		id := fmt.Sprintf("WORK%d", i)
+		data[i] = ShakespeareWork{ID: id, Title: id, Content: content.String()}
+	}
+	return data
+}
+
+// benchmarkStores runs fn in a sub-benchmark named after each backend, with a store
+// of the backend with the benchmark corpus indexed
+func benchmarkStores(b *testing.B, fn func(b *testing.B, s testStore)) {
+	stores := newTestStores(newBenchmarkCorpus(10, 1000))
+	for _, name := range testStoreNames {
+		s := stores[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				fn(b, s)
+			}
+		})
+	}
+}
+
+func BenchmarkStores_BatchIndex(b *testing.B) {
+	data := newBenchmarkCorpus(10, 1000)
+	for _, name := range testStoreNames {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				var s testStore = NewMemoryStore()
+				if name == "bleve" {
+					s = newTestStore(nil)
+				}
+				b.StartTimer()
+				if err := s.BatchIndex(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStores_Search(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, s testStore) {
+		if _, err := s.Search(SearchOptions{Query: "outrageous fortune", PageNumber: 1, PageSize: 10}); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func BenchmarkStores_Phrase(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, s testStore) {
+		options := SearchOptions{
+			Structured: &QueryClause{Phrase: &PhraseClause{Text: "sea of troubles"}},
+			PageNumber: 1,
+			PageSize:   10,
+		}
+		if _, err := s.Search(options); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func BenchmarkStores_Fuzzy(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, s testStore) {
+		if _, err := s.Search(SearchOptions{Query: "slumber", Fuzziness: 2, PageNumber: 1, PageSize: 10}); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func BenchmarkStores_Scan(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, s testStore) {
+		err := s.Scan(SearchOptions{Query: "mind"}, func(Hit) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	})
+}
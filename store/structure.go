@@ -0,0 +1,233 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockKind is the kind of a block of lines
+type BlockKind string
+
+const (
+	// BlockSpeech is a speech of a character
+	BlockSpeech BlockKind = "speech"
+	// BlockStage is a stage direction
+	BlockStage BlockKind = "stage"
+	// BlockText is any other text (front matter, poems, ...)
+	BlockText BlockKind = "text"
+)
+
+var (
+	actPattern     = regexp.MustCompile(`^ACT ([IVXL]+)\.?$`)
+	scenePattern   = regexp.MustCompile(`^SCENE ([IVXL]+)\.?`)
+	partPattern    = regexp.MustCompile(`^(?:THE )?(PROLOGUE|EPILOGUE|INDUCTION)\.?$`)
+	speakerPattern = regexp.MustCompile(`^[A-Z][A-Z’'&, -]*[A-Z]\.$`)
+	romanPattern   = regexp.MustCompile(`^[IVXL]+\.$`)
+	stagePattern   = regexp.MustCompile(`^(?:\[|Enter\b|Exit\b|Exeunt\b|Re-enter\b)`)
+)
+
+// Block represents consecutive lines with the same role, e.g. a speech
+type Block struct {
+	Kind    BlockKind `json:"kind"`
+	Speaker string    `json:"speaker,omitempty"`
+	Lines   []Line    `json:"lines"`
+}
+
+// Scene represents a scene, or a prologue, epilogue or induction of a play
+type Scene struct {
+	Type   string  `json:"type"` // scene, prologue, epilogue or induction
+	Number int     `json:"number,omitempty"`
+	Head   string  `json:"head"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Act represents an act of a play. Parts before the first act are in an act numbered 0.
+type Act struct {
+	Number int     `json:"number"`
+	Head   string  `json:"head,omitempty"`
+	Scenes []Scene `json:"scenes"`
+}
+
+// Structure represents the divisions of a work. Plays have acts, and the lines outside
+// of them (front matter of plays, the whole text of poems) are in Blocks.
+type Structure struct {
+	Blocks []Block `json:"blocks,omitempty"`
+	Acts   []Act   `json:"acts,omitempty"`
+}
+
+// romanToInt converts a roman numeral to an integer
+func romanToInt(s string) int {
+	values := map[rune]int{'I': 1, 'V': 5, 'X': 10, 'L': 50}
+	total, prev := 0, 0
+	runes := []rune(s)
+	for i := len(runes) - 1; i >= 0; i-- {
+		v := values[runes[i]]
+		if v < prev {
+			total -= v
+		} else {
+			total += v
+			prev = v
+		}
+	}
+	return total
+}
+
+// lineGap returns the smallest difference between the numbers of consecutive lines,
+// which separates lines of the same paragraph
+func lineGap(lines []Line) int {
+	gap := 0
+	for i := 1; i < len(lines); i++ {
+		if d := lines[i].LineNumber - lines[i-1].LineNumber; gap == 0 || d < gap {
+			gap = d
+		}
+	}
+	return gap
+}
+
+// bodyStart returns the index of the line starting the acts of a play, or len(lines)
+// if the work has none. The table of contents lists the acts too, so the body starts
+// at the last first act, or at the prologue or induction preceding it.
+func bodyStart(lines []Line) int {
+	start, contents := len(lines), 0
+	for i, line := range lines {
+		if m := actPattern.FindStringSubmatch(strings.TrimSpace(line.Text)); m != nil && romanToInt(m[1]) == 1 {
+			contents, start = start, i
+		}
+	}
+	if start == len(lines) {
+		return start
+	}
+	if contents == len(lines) {
+		contents = 0
+	}
+	for i := contents; i < start; i++ {
+		if partPattern.MatchString(strings.TrimSpace(lines[i].Text)) {
+			return i
+		}
+	}
+	return start
+}
+
+// textBlocks splits lines into text blocks at paragraph breaks
+func textBlocks(lines []Line, gap int) []Block {
+	var blocks []Block
+	for i, line := range lines {
+		if i == 0 || line.LineNumber-lines[i-1].LineNumber > gap {
+			blocks = append(blocks, Block{Kind: BlockText})
+		}
+		blocks[len(blocks)-1].Lines = append(blocks[len(blocks)-1].Lines, line)
+	}
+	return blocks
+}
+
+func isSpeaker(text string) bool {
+	return speakerPattern.MatchString(text) && !romanPattern.MatchString(text) && text != "THE END."
+}
+
+func isStageDirection(line Line) bool {
+	return strings.HasPrefix(line.Text, " ") || stagePattern.MatchString(strings.TrimSpace(line.Text))
+}
+
+// parseStructure detects the acts, scenes, speeches and stage directions of a work
+// from the layout of its text. Works without acts only have text blocks.
+func parseStructure(lines []Line) Structure {
+	gap := lineGap(lines)
+	start := bodyStart(lines)
+	structure := Structure{Blocks: textBlocks(lines[:start], gap)}
+
+	var act *Act
+	var scene *Scene
+	var block *Block
+	speaker := ""
+	newScene := func(s Scene) {
+		if act == nil {
+			structure.Acts = append(structure.Acts, Act{})
+			act = &structure.Acts[len(structure.Acts)-1]
+		}
+		act.Scenes = append(act.Scenes, s)
+		scene = &act.Scenes[len(act.Scenes)-1]
+		block = nil
+		speaker = ""
+	}
+	newBlock := func(b Block) {
+		if scene == nil {
+			newScene(Scene{Type: "scene"})
+		}
+		scene.Blocks = append(scene.Blocks, b)
+		block = &scene.Blocks[len(scene.Blocks)-1]
+	}
+
+	for i, line := range lines[start:] {
+		text := strings.TrimSpace(line.Text)
+		if m := actPattern.FindStringSubmatch(text); m != nil {
+			structure.Acts = append(structure.Acts, Act{Number: romanToInt(m[1]), Head: text})
+			act = &structure.Acts[len(structure.Acts)-1]
+			scene, block, speaker = nil, nil, ""
+			continue
+		}
+		if m := scenePattern.FindStringSubmatch(text); m != nil {
+			newScene(Scene{Type: "scene", Number: romanToInt(m[1]), Head: text})
+			continue
+		}
+		if m := partPattern.FindStringSubmatch(text); m != nil {
+			newScene(Scene{Type: strings.ToLower(m[1]), Head: text})
+			continue
+		}
+		if isSpeaker(text) {
+			speaker = strings.TrimSuffix(text, ".")
+			newBlock(Block{Kind: BlockSpeech, Speaker: speaker})
+			continue
+		}
+		if isStageDirection(line) {
+			// block is only set after the first line so there is a previous line
+			if block == nil || block.Kind != BlockStage || line.LineNumber-lines[start+i-1].LineNumber > gap {
+				newBlock(Block{Kind: BlockStage})
+			}
+			block.Lines = append(block.Lines, line)
+			continue
+		}
+		if block == nil || block.Kind == BlockStage {
+			// a speech goes on after a stage direction without repeating the speaker
+			if speaker != "" {
+				newBlock(Block{Kind: BlockSpeech, Speaker: speaker})
+			} else {
+				newBlock(Block{Kind: BlockText})
+			}
+		}
+		block.Lines = append(block.Lines, line)
+	}
+	return structure
+}
+
+// eachBlock calls fn with every block of the structure in text order
+func (s Structure) eachBlock(fn func(block Block)) {
+	for _, block := range s.Blocks {
+		fn(block)
+	}
+	for _, act := range s.Acts {
+		for _, scene := range act.Scenes {
+			for _, block := range scene.Blocks {
+				fn(block)
+			}
+		}
+	}
+}
+
+// lineBlocks returns the block of each line in a block keyed by line number
+func (s Structure) lineBlocks() map[int]Block {
+	lineBlocks := make(map[int]Block)
+	s.eachBlock(func(block Block) {
+		for _, line := range block.Lines {
+			lineBlocks[line.LineNumber] = block
+		}
+	})
+	return lineBlocks
+}
+
+// Structure returns the divisions of the work given by its markup, or else detected from its text
+func (w ShakespeareWork) Structure() Structure {
+	if w.Markup != nil {
+		return *w.Markup
+	}
+	return parseStructure(w.Lines())
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPlay = `MACBETH
+
+Contents
+
+ACT I
+Scene I. An open Place.
+
+Dramatis Personæ
+
+DUNCAN, King of Scotland.
+
+ACT I
+
+SCENE I. An open Place.
+
+ Thunder and Lightning. Enter three Witches.
+
+FIRST WITCH.
+When shall we three meet again?
+In thunder, lightning, or in rain?
+
+SECOND WITCH.
+When the hurlyburly’s done,
+
+ [_Aside._]
+
+When the battle’s lost and won.
+
+ [_Exeunt._]
+
+ACT II
+
+SCENE I. Inverness. Court within the Castle.
+
+Enter Banquo.
+
+BANQUO.
+How goes the night, boy?`
+
+func TestParseStructure_Play(t *testing.T) {
+	structure := ShakespeareWork{Content: testPlay}.Structure()
+
+	assert.Equal(t, []Block{
+		{Kind: BlockText, Lines: []Line{{LineNumber: 1, Text: "MACBETH"}}},
+		{Kind: BlockText, Lines: []Line{{LineNumber: 3, Text: "Contents"}}},
+		{Kind: BlockText, Lines: []Line{{LineNumber: 5, Text: "ACT I"}, {LineNumber: 6, Text: "Scene I. An open Place."}}},
+		{Kind: BlockText, Lines: []Line{{LineNumber: 8, Text: "Dramatis Personæ"}}},
+		{Kind: BlockText, Lines: []Line{{LineNumber: 10, Text: "DUNCAN, King of Scotland."}}},
+	}, structure.Blocks)
+
+	assert.Equal(t, 2, len(structure.Acts))
+	act := structure.Acts[0]
+	assert.Equal(t, 1, act.Number)
+	assert.Equal(t, "ACT I", act.Head)
+	assert.Equal(t, 1, len(act.Scenes))
+	scene := act.Scenes[0]
+	assert.Equal(t, "scene", scene.Type)
+	assert.Equal(t, 1, scene.Number)
+	assert.Equal(t, "SCENE I. An open Place.", scene.Head)
+
+	var kinds, speakers []string
+	for _, block := range scene.Blocks {
+		kinds = append(kinds, string(block.Kind))
+		speakers = append(speakers, block.Speaker)
+	}
+	assert.Equal(t, []string{"stage", "speech", "speech", "stage", "speech", "stage"}, kinds)
+	assert.Equal(t, []string{"", "FIRST WITCH", "SECOND WITCH", "", "SECOND WITCH", ""}, speakers)
+	assert.Equal(t, []Line{
+		{LineNumber: 19, Text: "When shall we three meet again?"},
+		{LineNumber: 20, Text: "In thunder, lightning, or in rain?"},
+	}, scene.Blocks[1].Lines)
+
+	scene = structure.Acts[1].Scenes[0]
+	assert.Equal(t, BlockStage, scene.Blocks[0].Kind)
+	assert.Equal(t, "BANQUO", scene.Blocks[1].Speaker)
+}
+
+func TestParseStructure_Prologue(t *testing.T) {
+	content := "ACT I\nScene I.\n\nTHE PROLOGUE\n\nCHORUS.\nTwo households\n\nACT I\n\nSCENE I. Verona.\n\nSAMPSON.\nGregory"
+	structure := ShakespeareWork{Content: content}.Structure()
+
+	assert.Equal(t, 1, len(structure.Blocks)) // table of contents
+	assert.Equal(t, 2, len(structure.Acts))
+	assert.Equal(t, 0, structure.Acts[0].Number)
+	assert.Equal(t, "prologue", structure.Acts[0].Scenes[0].Type)
+	assert.Equal(t, "CHORUS", structure.Acts[0].Scenes[0].Blocks[0].Speaker)
+	assert.Equal(t, 1, structure.Acts[1].Number)
+}
+
+func TestParseStructure_Poem(t *testing.T) {
+	content := "\n\nI.\n\nFrom fairest creatures we desire increase,\n\nThat thereby beauty’s rose might never die,\n\n\n\nII.\n\nWhen forty winters"
+	structure := ShakespeareWork{Content: content}.Structure()
+
+	assert.Empty(t, structure.Acts)
+	assert.Equal(t, 2, len(structure.Blocks))
+	assert.Equal(t, 3, len(structure.Blocks[0].Lines))
+	assert.Equal(t, "II.", structure.Blocks[1].Lines[0].Text)
+}
+
+func TestRomanToInt(t *testing.T) {
+	testCases := []struct {
+		roman    string
+		expected int
+	}{
+		{roman: "I", expected: 1},
+		{roman: "IV", expected: 4},
+		{roman: "IX", expected: 9},
+		{roman: "XIV", expected: 14},
+		{roman: "XL", expected: 40},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.roman, func(t *testing.T) {
+			assert.Equal(t, tc.expected, romanToInt(tc.roman))
+		})
+	}
+}
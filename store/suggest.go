@@ -0,0 +1,191 @@
+package store
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSuggestionDistance is the maximum edit distance between a query term and its suggestion
+const maxSuggestionDistance = 2
+
+// Suggestion represents a spelling correction for a query term
+type Suggestion struct {
+	Term       string `json:"term"`
+	Suggestion string `json:"suggestion"`
+}
+
+// candidate keeps track of the closest dictionary word found for a query term
+type candidate struct {
+	term     string
+	length   int
+	word     string
+	distance int
+	count    uint64
+	found    bool
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// analyzeWords splits text into lowercased words the same way the store indexes them
+func (c *corpus) analyzeWords(text string) []string {
+	var words []string
+	for _, token := range c.tokenize(text) {
+		words = append(words, token.term)
+	}
+	return words
+}
+
+// dictionaryFunc calls visit with every word indexed by a store and its number of occurrences
+type dictionaryFunc func(visit func(word string, count uint64)) error
+
+// suggest looks up the dictionary for the closest word to each query term that is
+// not in the index. Terms already in the index are left out of the result.
+func (c *corpus) suggest(query string, dictionary dictionaryFunc) ([]Suggestion, error) {
+	var candidates []*candidate
+	for _, term := range strings.Fields(query) {
+		for _, word := range c.analyzeWords(term) {
+			candidates = append(candidates, &candidate{
+				term:     word,
+				length:   utf8.RuneCountInString(word),
+				distance: maxSuggestionDistance + 1,
+			})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	err := dictionary(func(word string, count uint64) {
+		length := utf8.RuneCountInString(word)
+		for _, cand := range candidates {
+			if cand.found || abs(length-cand.length) > maxSuggestionDistance {
+				continue
+			}
+			distance := levenshtein(cand.term, word)
+			if distance == 0 {
+				cand.found = true
+				continue
+			}
+			if distance < cand.distance || (distance == cand.distance && count > cand.count) {
+				cand.word = word
+				cand.distance = distance
+				cand.count = count
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []Suggestion
+	for _, cand := range candidates {
+		if cand.found || cand.word == "" {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{Term: cand.term, Suggestion: cand.word})
+	}
+	return suggestions, nil
+}
+
+// withSuggestions adds spelling suggestions to a search result without hits. If the
+// options ask for it, the corrected query is searched instead.
+func (c *corpus) withSuggestions(options SearchOptions, result SearchResult, dictionary dictionaryFunc, search func(SearchOptions) (SearchResult, error)) (SearchResult, error) {
+	if result.Meta.TotalResults > 0 || options.Query == "" || options.Structured != nil {
+		return result, nil
+	}
+
+	suggestions, err := c.suggest(options.Query, dictionary)
+	if err != nil {
+		return result, err
+	}
+	result.Meta.Suggestions = suggestions
+	if !options.Autocorrect || len(suggestions) == 0 {
+		return result, nil
+	}
+
+	corrected := options
+	corrected.Query = c.correctQuery(options.Query, suggestions)
+	corrected.Autocorrect = false
+	correctedResult, err := search(corrected)
+	if err != nil {
+		return result, err
+	}
+	correctedResult.Meta.CorrectedQuery = corrected.Query
+	correctedResult.Meta.Suggestions = suggestions
+	return correctedResult, nil
+}
+
+// newSearchResult returns an empty result of a search with the options
+func newSearchResult(options SearchOptions) SearchResult {
+	return SearchResult{
+		Data: make([]Hit, 0), // serialized to [] not null for easier parsing.
+		Meta: Meta{
+			Highlight: Highlight{
+				PreTag:  "<mark>",
+				PostTag: "</mark>",
+			},
+			PageNumber: options.PageNumber,
+			PageSize:   options.PageSize,
+		},
+	}
+}
+
+// correctQuery replaces misspelled terms of a query with their suggestions. Terms are
+// analyzed the same way as by suggest, so punctuation or case does not keep a term,
+// e.g. "Hamlte,", from being corrected.
+func (c *corpus) correctQuery(query string, suggestions []Suggestion) string {
+	replacements := make(map[string]string, len(suggestions))
+	for _, s := range suggestions {
+		replacements[s.Term] = s.Suggestion
+	}
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		words := c.analyzeWords(term)
+		corrected := false
+		for i, word := range words {
+			if replacement, ok := replacements[word]; ok {
+				words[i] = replacement
+				corrected = true
+			}
+		}
+		if corrected {
+			term = strings.Join(words, " ")
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	testCases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "blood", b: "blood", expected: 0},
+		{a: "blod", b: "blood", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+		{a: "’tis", b: "tis", expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.expected, levenshtein(tc.a, tc.b))
+		})
+	}
+}
+
+func TestCorpus_CorrectQuery(t *testing.T) {
+	c := newCorpus(tokenizeWords)
+	suggestions := []Suggestion{{Term: "hamlte", Suggestion: "hamlet"}, {Term: "prnce", Suggestion: "prince"}}
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "Hamlte prince", expected: "hamlet prince"},
+		{query: "Hamlte, prince", expected: "hamlet prince"},
+		{query: "\"Hamlte\" Prince", expected: "hamlet Prince"},
+		{query: "hamlte-prnce", expected: "hamlet prince"},
+		{query: "good night", expected: "good night"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.query, func(t *testing.T) {
+			assert.Equal(t, tC.expected, c.correctQuery(tC.query, suggestions))
+		})
+	}
+}
+
+func TestBleveStore_Search_Suggestions(t *testing.T) {
+	data := []ShakespeareWork{
+		{ID: "1", Title: "First Title", Content: "out damned spot\nthe spot of blood"},
+		{ID: "2", Title: "Second Title", Content: "bloody instructions"},
+	}
+	searcher := newTestStore(data)
+
+	testCases := []struct {
+		name           string
+		options        SearchOptions
+		suggestions    []Suggestion
+		correctedQuery string
+		total          int
+	}{
+		{
+			name:    "no suggestions if results found",
+			options: SearchOptions{Query: "spot", PageNumber: 1, PageSize: 10},
+			total:   2,
+		},
+		{
+			name:        "suggest misspelled terms",
+			options:     SearchOptions{Query: "damed blod", PageNumber: 1, PageSize: 10},
+			suggestions: []Suggestion{{Term: "damed", Suggestion: "damned"}, {Term: "blod", Suggestion: "blood"}},
+		},
+		{
+			name:           "autocorrect",
+			options:        SearchOptions{Query: "blod", Autocorrect: true, PageNumber: 1, PageSize: 10},
+			suggestions:    []Suggestion{{Term: "blod", Suggestion: "blood"}},
+			correctedQuery: "blood",
+			total:          1,
+		},
+		{
+			name:           "autocorrect with punctuation",
+			options:        SearchOptions{Query: "Blod,", Autocorrect: true, PageNumber: 1, PageSize: 10},
+			suggestions:    []Suggestion{{Term: "blod", Suggestion: "blood"}},
+			correctedQuery: "blood",
+			total:          1,
+		},
+		{
+			name:    "no suggestions if nothing is close",
+			options: SearchOptions{Query: "xyzzy", Autocorrect: true, PageNumber: 1, PageSize: 10},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := searcher.Search(tc.options)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.suggestions, result.Meta.Suggestions)
+			assert.Equal(t, tc.correctedQuery, result.Meta.CorrectedQuery)
+			assert.Equal(t, tc.total, result.Meta.TotalResults)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+	"github.com/sankt-petersbug/shakesearch/prosody"
+)
+
+// UsePronunciations sets the pronunciations used to find the meter and rhyme of lines,
+// which are otherwise estimated from their spelling. Lines indexed before keep theirs,
+// so it must be called before BatchIndex.
+func (c *corpus) UsePronunciations(dict prosody.Dictionary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verse = prosody.NewAnalyzer(dict)
+}
+
+// validateMeter returns an error if the meter of the options is unknown
+func validateMeter(options SearchOptions) error {
+	if options.Meter == "" {
+		return nil
+	}
+	for _, meter := range prosody.Meters() {
+		if meter == options.Meter {
+			return nil
+		}
+	}
+	return invalidOptions("invalid meter: %s", options.Meter)
+}
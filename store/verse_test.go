@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/prosody"
+)
+
+func TestStores_Verse(t *testing.T) {
+	dict, err := prosody.ReadDictionary(strings.NewReader("AWAY  AH0 W EY1\nDAY  D EY1\nCOMPARE  K AH0 M P EH1 R\nSUMMER'S  S AH1 M ER0 Z\n"))
+	assert.Nil(t, err)
+	data := []ShakespeareWork{
+		{ID: "1", Title: "TitleA", Content: "Shall I compare thee to a summer's day?\nThou art more lovely and more temperate:\nSo fly away"},
+	}
+
+	testCases := []struct {
+		name     string
+		options  SearchOptions
+		expected []int
+	}{
+		{name: "meter", options: SearchOptions{Meter: "iambic-pentameter"}, expected: []int{1, 2}},
+		// line 2 has unknown stresses so it also scans as the other meters of its syllables
+		{name: "other meter", options: SearchOptions{Meter: "trochaic-hexameter"}, expected: []int{2}},
+		{name: "rhyme", options: SearchOptions{Rhyme: "Day"}, expected: []int{1, 3}},
+		{name: "meter and rhyme", options: SearchOptions{Meter: "iambic-dimeter", Rhyme: "day"}, expected: []int{3}},
+		{name: "substring and rhyme", options: SearchOptions{Query: "fly", Mode: ModeSubstring, Rhyme: "away"}, expected: []int{3}},
+		{name: "no rhyme", options: SearchOptions{Rhyme: "compare"}, expected: nil},
+	}
+	// pronunciations are used by the lines indexed after they are set
+	forEachStore(t, nil, func(t *testing.T, s testStore) {
+		s.UsePronunciations(dict)
+		assert.Nil(t, s.BatchIndex(data))
+		for _, tC := range testCases {
+			t.Run(tC.name, func(t *testing.T) {
+				tC.options.PageNumber = 1
+				tC.options.PageSize = 10
+				tC.options.SortBy = []string{"LineNumber"}
+				result, err := s.Search(tC.options)
+				assert.Nil(t, err)
+				var lineNumbers []int
+				for _, hit := range result.Data {
+					lineNumbers = append(lineNumbers, hit.LineNumber)
+				}
+				assert.Equal(t, tC.expected, lineNumbers)
+			})
+		}
+
+		t.Run("facet", func(t *testing.T) {
+			result, err := s.Search(SearchOptions{PageNumber: 1, PageSize: 10, Facets: map[string]int{"meter": 10}})
+			assert.Nil(t, err)
+			assert.Equal(t, []FacetCount{
+				{Count: 2, Term: "iambic-pentameter"},
+				{Count: 1, Term: "iambic-dimeter"},
+				{Count: 1, Term: "trochaic-hexameter"},
+			}, result.Meta.Facets["meter"])
+		})
+
+		t.Run("errors", func(t *testing.T) {
+			_, err := s.Search(SearchOptions{PageNumber: 1, PageSize: 10, Meter: "iambic"})
+			assert.True(t, errors.Is(err, ErrInvalidSearchOptions))
+			_, err = s.Search(SearchOptions{PageNumber: 1, PageSize: 10, Rhyme: "hmm"})
+			assert.True(t, errors.Is(err, ErrInvalidSearchOptions))
+		})
+	})
+}
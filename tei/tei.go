@@ -0,0 +1,328 @@
+// Package tei reads Shakespeare's works from TEI XML documents (e.g. the Folger
+// Shakespeare or MOSH editions), keeping the acts, scenes, speeches and stage
+// directions given by their markup.
+package tei
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+// ErrNoTitle is returned when a TEI document has no title
+var ErrNoTitle = errors.New("TEI document has no title")
+
+// node is an element or the text (with an empty name) of a TEI document
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	chars    string
+}
+
+// skipped are the elements whose text is not part of the work
+var skipped = map[string]bool{
+	"note": true,
+	"fw":   true,
+}
+
+// parts are the types of divisions read as scenes
+var parts = map[string]bool{
+	"scene":     true,
+	"prologue":  true,
+	"epilogue":  true,
+	"induction": true,
+}
+
+func parse(r io.Reader) (*node, error) {
+	dec := xml.NewDecoder(r)
+	root := &node{}
+	stack := []*node{root}
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				n.attrs[attr.Name.Local] = attr.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.children = append(parent.children, &node{chars: string(t)})
+		}
+	}
+}
+
+// find returns the first descendant element with the name
+func (n *node) find(name string) *node {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+		if found := child.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// child returns the first child element with the name
+func (n *node) child(name string) *node {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// lines returns the text of a node split at line breaks, with whitespace collapsed
+func (n *node) lines() []string {
+	var lines []string
+	var current strings.Builder
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.name == "lb" {
+			lines = append(lines, current.String())
+			current.Reset()
+			return
+		}
+		if skipped[n.name] {
+			return
+		}
+		current.WriteString(n.chars)
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(n)
+	lines = append(lines, current.String())
+
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if text := strings.Join(strings.Fields(line), " "); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// text returns the text of a node on a single line
+func (n *node) text() string {
+	return strings.Join(n.lines(), " ")
+}
+
+// builder builds the content and structure of a work. Every line of the content
+// is non-empty so that line numbers match the ones of ShakespeareWork.Lines.
+type builder struct {
+	content   []string
+	structure store.Structure
+	act       *store.Act
+	scene     *store.Scene
+	block     *store.Block
+}
+
+func (b *builder) addLine(text string) store.Line {
+	b.content = append(b.content, text)
+	return store.Line{LineNumber: len(b.content), Text: text}
+}
+
+func (b *builder) startAct(number int, head string) {
+	if head != "" {
+		b.addLine(head)
+	}
+	b.structure.Acts = append(b.structure.Acts, store.Act{Number: number, Head: head})
+	b.act = &b.structure.Acts[len(b.structure.Acts)-1]
+	b.scene, b.block = nil, nil
+}
+
+func (b *builder) startScene(scene store.Scene) {
+	if scene.Head != "" {
+		b.addLine(scene.Head)
+	}
+	if b.act == nil {
+		b.startAct(0, "")
+	}
+	b.act.Scenes = append(b.act.Scenes, scene)
+	b.scene = &b.act.Scenes[len(b.act.Scenes)-1]
+	b.block = nil
+}
+
+// startBlock starts a block in the current scene, or in the front matter outside of acts
+func (b *builder) startBlock(kind store.BlockKind, speaker string) {
+	blocks := &b.structure.Blocks
+	if b.scene != nil {
+		blocks = &b.scene.Blocks
+	}
+	*blocks = append(*blocks, store.Block{Kind: kind, Speaker: speaker})
+	b.block = &(*blocks)[len(*blocks)-1]
+}
+
+func (b *builder) addLines(kind store.BlockKind, speaker string, texts []string) {
+	if len(texts) == 0 {
+		return
+	}
+	if b.block == nil || b.block.Kind != kind || b.block.Speaker != speaker {
+		b.startBlock(kind, speaker)
+	}
+	for _, text := range texts {
+		b.block.Lines = append(b.block.Lines, b.addLine(text))
+	}
+}
+
+func divNumber(n *node, count int) int {
+	if number, err := strconv.Atoi(n.attrs["n"]); err == nil {
+		return number
+	}
+	return count + 1
+}
+
+func (b *builder) walkDiv(n *node) {
+	head := ""
+	if h := n.child("head"); h != nil {
+		head = h.text()
+	}
+	switch typ := strings.ToLower(n.attrs["type"]); {
+	case typ == "act":
+		b.startAct(divNumber(n, len(b.structure.Acts)), head)
+	case parts[typ]:
+		scene := store.Scene{Type: typ, Head: head}
+		if typ == "scene" {
+			count := 0
+			if b.act != nil {
+				count = len(b.act.Scenes)
+			}
+			scene.Number = divNumber(n, count)
+		}
+		b.startScene(scene)
+	default:
+		if head != "" {
+			b.addLines(store.BlockText, "", []string{head})
+		}
+	}
+	for _, child := range n.children {
+		if child.name != "head" {
+			b.walk(child, "")
+		}
+	}
+}
+
+// walk adds the lines of a node, in the speech of speaker if it is not empty
+func (b *builder) walk(n *node, speaker string) {
+	switch n.name {
+	case "teiHeader", "note", "fw":
+	case "div", "div1", "div2", "div3":
+		b.walkDiv(n)
+	case "sp":
+		if s := n.child("speaker"); s != nil {
+			speaker = strings.TrimSuffix(s.text(), ".")
+			b.addLine(s.text())
+		}
+		b.block = nil // a speech is a new block even if the speaker speaks again
+		for _, child := range n.children {
+			if child.name != "speaker" {
+				b.walk(child, speaker)
+			}
+		}
+	case "stage":
+		b.startBlock(store.BlockStage, "")
+		b.addLines(store.BlockStage, "", []string{n.text()})
+	case "l", "p", "ab", "head", "castItem", "item":
+		kind := store.BlockText
+		if speaker != "" {
+			kind = store.BlockSpeech
+		}
+		b.addLines(kind, speaker, n.lines())
+	default:
+		if n.name == "" {
+			// text outside of lines, e.g. in a speech without l or p elements
+			if speaker != "" {
+				b.addLines(store.BlockSpeech, speaker, n.lines())
+			}
+			return
+		}
+		for _, child := range n.children {
+			b.walk(child, speaker)
+		}
+	}
+}
+
+// Read reads a work from a TEI document. The text of the work is made of the
+// headings, speakers and lines of the document, one per line.
+func Read(r io.Reader) (store.ShakespeareWork, error) {
+	var work store.ShakespeareWork
+	root, err := parse(r)
+	if err != nil {
+		return work, err
+	}
+	if header := root.find("teiHeader"); header != nil {
+		if title := header.find("title"); title != nil {
+			work.Title = title.text()
+		}
+	}
+	if work.Title == "" {
+		return work, ErrNoTitle
+	}
+
+	b := &builder{}
+	if text := root.find("text"); text != nil {
+		b.walk(text, "")
+	}
+	work.Content = strings.Join(b.content, "\n")
+	work.Markup = &b.structure
+	return work, nil
+}
+
+// ReadDir reads the works of the .xml files of a directory, ordered by file name
+func ReadDir(dir string) ([]store.ShakespeareWork, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	var works []store.ShakespeareWork
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".xml" {
+			continue
+		}
+		work, err := readFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Read %s from %s", work.Title, file.Name())
+		works = append(works, work)
+	}
+	return works, nil
+}
+
+func readFile(path string) (store.ShakespeareWork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return store.ShakespeareWork{}, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,139 @@
+package tei
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sankt-petersbug/shakesearch/store"
+)
+
+const play = `<?xml version="1.0" encoding="UTF-8"?>
+<TEI xmlns="http://www.tei-c.org/ns/1.0">
+  <teiHeader>
+    <fileDesc><titleStmt><title>The Tempest</title></titleStmt></fileDesc>
+  </teiHeader>
+  <text>
+    <front>
+      <castList>
+        <castItem>PROSPERO, the right Duke of Milan</castItem>
+        <castItem>MIRANDA, daughter to Prospero</castItem>
+      </castList>
+    </front>
+    <body>
+      <div type="act" n="1">
+        <head>ACT I</head>
+        <div type="scene" n="2">
+          <head>SCENE II. The island.</head>
+          <stage>Enter Prospero and Miranda.</stage>
+          <sp>
+            <speaker>MIRANDA.</speaker>
+            <l>If by your art, my dearest father, you have</l>
+            <l>Put the wild waters in this roar, allay them.</l>
+          </sp>
+          <sp>
+            <speaker>PROSPERO.</speaker>
+            <l>Be collected.<note>Calm yourself.</note></l>
+            <stage>Lays down his mantle.</stage>
+            <p>Lie there, my art.<lb/>Wipe thou thine eyes.</p>
+          </sp>
+        </div>
+      </div>
+    </body>
+  </text>
+</TEI>
+`
+
+func TestRead(t *testing.T) {
+	work, err := Read(strings.NewReader(play))
+	assert.NoError(t, err)
+	assert.Equal(t, "The Tempest", work.Title)
+	assert.Equal(t, strings.Join([]string{
+		"PROSPERO, the right Duke of Milan",
+		"MIRANDA, daughter to Prospero",
+		"ACT I",
+		"SCENE II. The island.",
+		"Enter Prospero and Miranda.",
+		"MIRANDA.",
+		"If by your art, my dearest father, you have",
+		"Put the wild waters in this roar, allay them.",
+		"PROSPERO.",
+		"Be collected.",
+		"Lays down his mantle.",
+		"Lie there, my art.",
+		"Wipe thou thine eyes.",
+	}, "\n"), work.Content)
+
+	line := func(n int) store.Line {
+		return work.Lines()[n-1]
+	}
+	expected := store.Structure{
+		Blocks: []store.Block{
+			{Kind: store.BlockText, Lines: []store.Line{line(1), line(2)}},
+		},
+		Acts: []store.Act{
+			{Number: 1, Head: "ACT I", Scenes: []store.Scene{
+				{Type: "scene", Number: 2, Head: "SCENE II. The island.", Blocks: []store.Block{
+					{Kind: store.BlockStage, Lines: []store.Line{line(5)}},
+					{Kind: store.BlockSpeech, Speaker: "MIRANDA", Lines: []store.Line{line(7), line(8)}},
+					{Kind: store.BlockSpeech, Speaker: "PROSPERO", Lines: []store.Line{line(10)}},
+					{Kind: store.BlockStage, Lines: []store.Line{line(11)}},
+					{Kind: store.BlockSpeech, Speaker: "PROSPERO", Lines: []store.Line{line(12), line(13)}},
+				}},
+			}},
+		},
+	}
+	assert.Equal(t, expected, work.Structure())
+}
+
+func TestRead_Errors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		document string
+	}{
+		{
+			desc:     "no title",
+			document: `<TEI><teiHeader></teiHeader><text><body><p>text</p></body></text></TEI>`,
+		},
+		{
+			desc:     "malformed",
+			document: `<TEI><teiHeader><title>Title</title></teiHeader><text>`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tC.document))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := Read(strings.NewReader(`<TEI><text><body><p>text</p></body></text></TEI>`))
+	assert.Equal(t, ErrNoTitle, err)
+}
+
+func TestReadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tei")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"b.xml":    play,
+		"a.xml":    `<TEI><teiHeader><title>Sonnets</title></teiHeader><text><body><lg><l>Shall I compare thee</l></lg></body></text></TEI>`,
+		"notes.md": "not a TEI document",
+	}
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	works, err := ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, works, 2) {
+		assert.Equal(t, "Sonnets", works[0].Title)
+		assert.Equal(t, "Shall I compare thee", works[0].Content)
+		assert.Equal(t, "The Tempest", works[1].Title)
+	}
+}